@@ -0,0 +1,112 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// BoltStore is a Store that keeps objects on disk in a bbolt database,
+// so the cache survives restarts. Each collection is a bucket, with the states in their own bucket.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+var statesBucket = []byte("_states")
+
+// OpenBoltStore opens the database at path, creating it when it doesn't exist.
+// Only one process can have it open at a time.
+func OpenBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cache database %s: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(statesBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize cache database: %w", err)
+	}
+	return &BoltStore{db: db}, nil
+}
+
+func (s *BoltStore) State(collection string) (state string, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		state = string(tx.Bucket(statesBucket).Get([]byte(collection)))
+		return nil
+	})
+	return state, err
+}
+
+func (s *BoltStore) Get(collection string, ids []string) (map[string]json.RawMessage, error) {
+	found := map[string]json.RawMessage{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(collection))
+		if b == nil {
+			return nil
+		}
+		for _, id := range ids {
+			// values are only valid during the transaction
+			if obj := b.Get([]byte(id)); obj != nil {
+				found[id] = append(json.RawMessage(nil), obj...)
+			}
+		}
+		return nil
+	})
+	return found, err
+}
+
+func (s *BoltStore) All(collection string) (map[string]json.RawMessage, error) {
+	all := map[string]json.RawMessage{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(collection))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			all[string(k)] = append(json.RawMessage(nil), v...)
+			return nil
+		})
+	})
+	return all, err
+}
+
+func (s *BoltStore) Update(collection string, changes *Changes) error {
+	name := []byte(collection)
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if changes.Reset {
+			if err := tx.DeleteBucket(name); err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+				return fmt.Errorf("failed to reset collection %s: %w", collection, err)
+			}
+		}
+		b, err := tx.CreateBucketIfNotExists(name)
+		if err != nil {
+			return fmt.Errorf("failed to create collection %s: %w", collection, err)
+		}
+		for _, id := range changes.Delete {
+			if err := b.Delete([]byte(id)); err != nil {
+				return fmt.Errorf("failed to delete %s from collection %s: %w", id, collection, err)
+			}
+		}
+		for id, obj := range changes.Put {
+			if err := b.Put([]byte(id), obj); err != nil {
+				return fmt.Errorf("failed to put %s in collection %s: %w", id, collection, err)
+			}
+		}
+		if len(changes.State) > 0 {
+			if err := tx.Bucket(statesBucket).Put(name, []byte(changes.State)); err != nil {
+				return fmt.Errorf("failed to set the state of collection %s: %w", collection, err)
+			}
+		}
+		return nil
+	})
+}
+
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
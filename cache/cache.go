@@ -0,0 +1,328 @@
+// Package cache keeps a local copy of an account's mailboxes, emails and threads,
+// serving reads from a Store and bringing it up to date through /changes.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/cwinters8/gomap/client"
+	"github.com/cwinters8/gomap/objects/emails"
+	"github.com/cwinters8/gomap/objects/mailboxes"
+	"github.com/cwinters8/gomap/objects/threads"
+	"github.com/cwinters8/gomap/requests"
+)
+
+// Cache serves the objects of one account from a Store.
+//
+// Every mailbox is held, while emails and threads are held once they've been read.
+// Objects are only fetched from the server when they aren't held; call Refresh,
+// or run Watch, to pick up changes made on the server.
+//
+//	store, err := cache.OpenBoltStore("mail.db")
+//	c := cache.New(jmapClient, "", store)
+//	go c.Watch(ctx)
+//	boxes, err := c.Mailboxes(ctx)
+type Cache struct {
+	// OnError is called by Watch with every refresh that fails; they're dropped when it's nil.
+	OnError func(error)
+
+	client    *client.Client
+	store     Store
+	mailboxes *collection[mailboxes.Mailbox]
+	emails    *collection[emails.Email]
+	threads   *collection[threads.Thread]
+}
+
+// New creates a cache of the account with acctID, or the client's mail account when acctID is empty.
+func New(c *client.Client, acctID string, store Store) *Cache {
+	if len(acctID) < 1 {
+		acctID = c.MailAccountID()
+	}
+	cache := Cache{client: c, store: store}
+	cache.mailboxes = &collection[mailboxes.Mailbox]{
+		cache:    &cache,
+		acctID:   acctID,
+		typ:      mailboxes.Type,
+		complete: true,
+		getCall: func(acctID string, ids []string) (*requests.Call, error) {
+			return mailboxes.Type.GetCall(acctID, &requests.GetArgs{IDs: ids})
+		},
+	}
+	cache.emails = &collection[emails.Email]{
+		cache:   &cache,
+		acctID:  acctID,
+		typ:     emails.Type,
+		getCall: emails.GetCall,
+	}
+	cache.threads = &collection[threads.Thread]{
+		cache:  &cache,
+		acctID: acctID,
+		typ:    threads.Type,
+		getCall: func(acctID string, ids []string) (*requests.Call, error) {
+			return threads.Type.GetCall(acctID, &requests.GetArgs{IDs: ids})
+		},
+	}
+	return &cache
+}
+
+// Mailboxes returns every mailbox of the account, loading them all the first time.
+func (c *Cache) Mailboxes(ctx context.Context) ([]*mailboxes.Mailbox, error) {
+	return c.mailboxes.all(ctx)
+}
+
+// Emails returns the emails with ids, in the same order, fetching those that aren't held.
+func (c *Cache) Emails(ctx context.Context, ids []string) (found []*emails.Email, notFound []string, err error) {
+	return c.emails.get(ctx, ids)
+}
+
+// Threads returns the threads with ids, in the same order, fetching those that aren't held.
+func (c *Cache) Threads(ctx context.Context, ids []string) (found []*threads.Thread, notFound []string, err error) {
+	return c.threads.get(ctx, ids)
+}
+
+// Refresh brings every held object up to date with the server.
+func (c *Cache) Refresh(ctx context.Context) error {
+	return errors.Join(
+		c.mailboxes.refresh(ctx),
+		c.emails.refresh(ctx),
+		c.threads.refresh(ctx),
+	)
+}
+
+// Watch refreshes the cache whenever the server reports a change to mailboxes, emails or threads
+// on its event source, until ctx is done. It returns the reason it stopped, as client.Listen does.
+//
+// A failed refresh doesn't stop Watch: the error goes to OnError and the data type is
+// refreshed again along with the next change the server reports.
+func (c *Cache) Watch(ctx context.Context) error {
+	cols := []interface {
+		changed(*client.StateChange) bool
+		refresh(context.Context) error
+	}{c.mailboxes, c.emails, c.threads}
+	stale := []bool{true, true, true}
+	refresh := func() {
+		for i, col := range cols {
+			if !stale[i] {
+				continue
+			}
+			err := col.refresh(ctx)
+			stale[i] = err != nil
+			if err != nil && ctx.Err() == nil && c.OnError != nil {
+				c.OnError(err)
+			}
+		}
+	}
+	refresh()
+	opts := client.EventSourceOptions{Types: []string{c.mailboxes.typ.Name, c.emails.typ.Name, c.threads.typ.Name}}
+	return c.client.Listen(ctx, &opts, func(change *client.StateChange) error {
+		for i, col := range cols {
+			if col.changed(change) {
+				stale[i] = true
+			}
+		}
+		refresh()
+		return nil
+	})
+}
+
+// collection is the cache of one data type.
+type collection[T any] struct {
+	mu      sync.Mutex
+	cache   *Cache
+	acctID  string
+	typ     requests.Type[T]
+	getCall func(acctID string, ids []string) (*requests.Call, error)
+	// complete collections hold every object rather than only those read
+	complete bool
+}
+
+func (col *collection[T]) name() string {
+	return col.acctID + "/" + col.typ.Name
+}
+
+func (col *collection[T]) changed(change *client.StateChange) bool {
+	_, ok := change.State(col.acctID, col.typ.Name)
+	return ok
+}
+
+func (col *collection[T]) get(ctx context.Context, ids []string) (found []*T, notFound []string, err error) {
+	col.mu.Lock()
+	defer col.mu.Unlock()
+	held, err := col.cache.store.Get(col.name(), ids)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s cache: %w", col.typ.Name, err)
+	}
+	var missing []string
+	for _, id := range ids {
+		if _, ok := held[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		fetched, notFetched, state, err := col.fetch(ctx, missing)
+		if err != nil {
+			return nil, nil, err
+		}
+		changes := Changes{Put: fetched}
+		if current, err := col.cache.store.State(col.name()); err == nil && len(current) < 1 {
+			// nothing else is held, so everything is in sync with this state
+			changes.State = state
+		}
+		if err := col.cache.store.Update(col.name(), &changes); err != nil {
+			return nil, nil, fmt.Errorf("failed to update %s cache: %w", col.typ.Name, err)
+		}
+		for id, obj := range fetched {
+			held[id] = obj
+		}
+		notFound = notFetched
+	}
+	for _, id := range ids {
+		obj, ok := held[id]
+		if !ok {
+			continue
+		}
+		v, err := decode[T](obj)
+		if err != nil {
+			return nil, nil, err
+		}
+		found = append(found, v)
+	}
+	return found, notFound, nil
+}
+
+func (col *collection[T]) all(ctx context.Context) ([]*T, error) {
+	col.mu.Lock()
+	defer col.mu.Unlock()
+	state, err := col.cache.store.State(col.name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s cache state: %w", col.typ.Name, err)
+	}
+	if len(state) < 1 {
+		if _, err := col.load(ctx); err != nil {
+			return nil, err
+		}
+	}
+	held, err := col.cache.store.All(col.name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s cache: %w", col.typ.Name, err)
+	}
+	list := make([]*T, 0, len(held))
+	for _, obj := range held {
+		v, err := decode[T](obj)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+	return list, nil
+}
+
+func (col *collection[T]) refresh(ctx context.Context) error {
+	col.mu.Lock()
+	defer col.mu.Unlock()
+	state, err := col.cache.store.State(col.name())
+	if err != nil {
+		return fmt.Errorf("failed to read %s cache state: %w", col.typ.Name, err)
+	}
+	if len(state) < 1 {
+		if col.complete {
+			_, err := col.load(ctx)
+			return err
+		}
+		// nothing is held yet
+		return nil
+	}
+	delta, err := col.typ.Sync(ctx, col.cache.client, col.acctID, &requests.SyncArgs{
+		SinceState: state,
+		Resync: func(ctx context.Context) (string, error) {
+			if col.complete {
+				return col.load(ctx)
+			}
+			state, err := col.typ.State(ctx, col.cache.client, col.acctID)
+			if err != nil {
+				return "", err
+			}
+			return state, col.cache.store.Update(col.name(), &Changes{Reset: true, State: state})
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to sync %s cache: %w", col.typ.Name, err)
+	}
+	if delta.Resynced {
+		return nil
+	}
+	var stale []string
+	if col.complete {
+		stale = append(stale, delta.Created...)
+		stale = append(stale, delta.Updated...)
+	} else {
+		// only refetch what's held
+		held, err := col.cache.store.Get(col.name(), delta.Updated)
+		if err != nil {
+			return fmt.Errorf("failed to read %s cache: %w", col.typ.Name, err)
+		}
+		for id := range held {
+			stale = append(stale, id)
+		}
+	}
+	changes := Changes{State: delta.NewState, Delete: delta.Destroyed}
+	if len(stale) > 0 {
+		fetched, notFound, _, err := col.fetch(ctx, stale)
+		if err != nil {
+			return err
+		}
+		changes.Put = fetched
+		changes.Delete = append(changes.Delete, notFound...)
+	}
+	if err := col.cache.store.Update(col.name(), &changes); err != nil {
+		return fmt.Errorf("failed to update %s cache: %w", col.typ.Name, err)
+	}
+	return nil
+}
+
+// load replaces the collection with every object on the server, returning their state.
+func (col *collection[T]) load(ctx context.Context) (string, error) {
+	fetched, _, state, err := col.fetch(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	if err := col.cache.store.Update(col.name(), &Changes{Reset: true, State: state, Put: fetched}); err != nil {
+		return "", fmt.Errorf("failed to update %s cache: %w", col.typ.Name, err)
+	}
+	return state, nil
+}
+
+// fetch gets the objects with ids from the server as raw JSON, or every object when ids is nil.
+func (col *collection[T]) fetch(ctx context.Context, ids []string) (found map[string]json.RawMessage, notFound []string, state string, err error) {
+	call, err := col.getCall(col.acctID, ids)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to construct %s/get call: %w", col.typ.Name, err)
+	}
+	resp, err := requests.SendCall[requests.GetResponse[json.RawMessage]](ctx, col.cache.client, call, requests.Using(col.typ.Using...))
+	if err != nil {
+		return nil, nil, "", err
+	}
+	found = make(map[string]json.RawMessage, len(resp.List))
+	for _, obj := range resp.List {
+		var ref struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(*obj, &ref); err != nil || len(ref.ID) < 1 {
+			return nil, nil, "", fmt.Errorf("%s/get returned an object without an id", col.typ.Name)
+		}
+		found[ref.ID] = *obj
+	}
+	return found, resp.NotFound, resp.State, nil
+}
+
+func decode[T any](obj json.RawMessage) (*T, error) {
+	var v T
+	if err := json.Unmarshal(obj, &v); err != nil {
+		return nil, fmt.Errorf("failed to decode cached object: %w", err)
+	}
+	return &v, nil
+}
@@ -0,0 +1,206 @@
+package cache_test
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cwinters8/gomap/cache"
+	"github.com/cwinters8/gomap/client"
+	"github.com/cwinters8/gomap/internal/jmaptest"
+	"github.com/cwinters8/gomap/utils"
+)
+
+// fakeMail serves mailboxes and emails that change once, from state 1 to state 2.
+type fakeMail struct {
+	mu      sync.Mutex
+	changed bool
+	calls   []string
+}
+
+func (f *fakeMail) handle(method string, args map[string]any) map[string]any {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, method)
+	state := "1"
+	if f.changed {
+		state = "2"
+	}
+	mailboxes := map[string]any{"MB1": map[string]any{"id": "MB1", "name": "Inbox"}, "MB2": map[string]any{"id": "MB2", "name": "Old"}}
+	emails := map[string]any{"M1": map[string]any{"id": "M1", "subject": "one"}, "M2": map[string]any{"id": "M2", "subject": "two"}}
+	if f.changed {
+		mailboxes = map[string]any{"MB1": map[string]any{"id": "MB1", "name": "Inbox"}, "MB3": map[string]any{"id": "MB3", "name": "New"}}
+		emails = map[string]any{"M1": map[string]any{"id": "M1", "subject": "one", "keywords": map[string]any{"$seen": true}}}
+	}
+	get := func(objects map[string]any) map[string]any {
+		list, notFound := []any{}, []any{}
+		ids, ok := args["ids"].([]any)
+		if !ok {
+			for _, obj := range objects {
+				list = append(list, obj)
+			}
+		}
+		for _, id := range ids {
+			if obj, ok := objects[id.(string)]; ok {
+				list = append(list, obj)
+			} else {
+				notFound = append(notFound, id)
+			}
+		}
+		return map[string]any{"state": state, "list": list, "notFound": notFound}
+	}
+	switch method {
+	case "Mailbox/get":
+		return get(mailboxes)
+	case "Email/get":
+		return get(emails)
+	case "Mailbox/changes":
+		return map[string]any{"oldState": "1", "newState": state, "created": []any{"MB3"}, "updated": []any{}, "destroyed": []any{"MB2"}}
+	case "Email/changes":
+		return map[string]any{"oldState": "1", "newState": state, "created": []any{"M3"}, "updated": []any{"M1"}, "destroyed": []any{"M2"}}
+	}
+	return map[string]any{"state": state, "list": []any{}}
+}
+
+func (f *fakeMail) server(t *testing.T) *client.Client {
+	return jmaptest.NewServer(t, func(method string, args map[string]any) (string, map[string]any) {
+		return method, f.handle(method, args)
+	}).NewClient()
+}
+
+func TestCache(t *testing.T) {
+	stores := map[string]func(t *testing.T) cache.Store{
+		"memory": func(t *testing.T) cache.Store {
+			return cache.NewMemoryStore()
+		},
+		"bolt": func(t *testing.T) cache.Store {
+			store, err := cache.OpenBoltStore(filepath.Join(t.TempDir(), "cache.db"))
+			if err != nil {
+				t.Fatalf("failed to open bolt store: %s", err.Error())
+			}
+			return store
+		},
+	}
+	for name, open := range stores {
+		t.Run(name, func(t *testing.T) {
+			f := fakeMail{}
+			store := open(t)
+			defer store.Close()
+			c := cache.New(f.server(t), "", store)
+			ctx := context.Background()
+
+			boxes, err := c.Mailboxes(ctx)
+			if err != nil {
+				t.Fatalf("failed to get mailboxes: %s", err.Error())
+			}
+			found, notFound, err := c.Emails(ctx, []string{"M2", "M1", "M9"})
+			if err != nil {
+				t.Fatalf("failed to get emails: %s", err.Error())
+			}
+			again, _, err := c.Emails(ctx, []string{"M1"})
+			if err != nil {
+				t.Fatalf("failed to get emails: %s", err.Error())
+			}
+			cases := utils.Cases{
+				utils.NewCase(len(boxes) != 2, "wanted 2 mailboxes; got %d", len(boxes)),
+				utils.NewCase(len(found) != 2 || found[0].ID != "M2" || found[1].ID != "M1", "wanted emails M2 and M1 in order; got %v", found),
+				utils.NewCase(!slices.Equal(notFound, []string{"M9"}), "wanted M9 not found; got %v", notFound),
+				utils.NewCase(len(again) != 1 || again[0].Subject != "one", "wanted cached email M1; got %v", again),
+				utils.NewCase(!slices.Equal(f.calls, []string{"Mailbox/get", "Email/get"}), "wanted cached emails to be served locally; got %v", f.calls),
+			}
+
+			f.changed = true
+			f.calls = nil
+			if err := c.Refresh(ctx); err != nil {
+				t.Fatalf("failed to refresh: %s", err.Error())
+			}
+			boxes, err = c.Mailboxes(ctx)
+			if err != nil {
+				t.Fatalf("failed to get mailboxes: %s", err.Error())
+			}
+			names := []string{}
+			for _, b := range boxes {
+				names = append(names, b.Name)
+			}
+			sort.Strings(names)
+			found, _, err = c.Emails(ctx, []string{"M1", "M2"})
+			if err != nil {
+				t.Fatalf("failed to get emails: %s", err.Error())
+			}
+			cases.Append(
+				utils.NewCase(!slices.Equal(names, []string{"Inbox", "New"}), "wanted mailboxes Inbox and New; got %v", names),
+				utils.NewCase(len(found) != 1 || found[0].Keywords == nil || !found[0].Keywords.Seen, "wanted only M1, now seen; got %v", found),
+			)
+			// M2 is no longer held, so it's asked for again
+			wanted := []string{"Mailbox/changes", "Mailbox/get", "Email/changes", "Email/get", "Email/get"}
+			cases.Append(utils.NewCase(!slices.Equal(f.calls, wanted), "wanted calls %v; got %v", wanted, f.calls))
+			cases.Iterator(func(c *utils.Case) {
+				t.Error(c.Message)
+			})
+		})
+	}
+}
+
+func TestWatch(t *testing.T) {
+	f := fakeMail{}
+	failed := false
+	srv := jmaptest.NewServer(t, func(method string, args map[string]any) (string, map[string]any) {
+		if method == "Mailbox/changes" && !failed {
+			failed = true
+			return "error", map[string]any{"type": "serverFail"}
+		}
+		return method, f.handle(method, args)
+	})
+	c := cache.New(srv.NewClient(), "", cache.NewMemoryStore())
+	errs := make(chan error, 1)
+	c.OnError = func(err error) {
+		errs <- err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := c.Mailboxes(ctx); err != nil {
+		t.Fatalf("failed to get mailboxes: %s", err.Error())
+	}
+	f.mu.Lock()
+	f.changed = true
+	f.mu.Unlock()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Watch(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+	select {
+	case <-errs:
+	case err := <-done:
+		t.Fatalf("wanted Watch to keep going after a failed refresh; got %v", err)
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the failed refresh")
+	}
+
+	srv.Push("Mailbox", "2")
+	var names []string
+	for ctx.Err() == nil {
+		boxes, err := c.Mailboxes(ctx)
+		if err != nil {
+			t.Fatalf("failed to get mailboxes: %s", err.Error())
+		}
+		names = nil
+		for _, b := range boxes {
+			names = append(names, b.Name)
+		}
+		sort.Strings(names)
+		if slices.Equal(names, []string{"Inbox", "New"}) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf("wanted the next change to be applied, leaving mailboxes Inbox and New; got %v", names)
+}
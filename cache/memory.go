@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// MemoryStore is a Store that keeps objects in memory, losing them when the process exits.
+type MemoryStore struct {
+	mu          sync.RWMutex
+	collections map[string]*memoryCollection
+}
+
+type memoryCollection struct {
+	state   string
+	objects map[string]json.RawMessage
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{collections: map[string]*memoryCollection{}}
+}
+
+func (s *MemoryStore) State(collection string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if col, ok := s.collections[collection]; ok {
+		return col.state, nil
+	}
+	return "", nil
+}
+
+func (s *MemoryStore) Get(collection string, ids []string) (map[string]json.RawMessage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	found := map[string]json.RawMessage{}
+	col, ok := s.collections[collection]
+	if !ok {
+		return found, nil
+	}
+	for _, id := range ids {
+		if obj, ok := col.objects[id]; ok {
+			found[id] = obj
+		}
+	}
+	return found, nil
+}
+
+func (s *MemoryStore) All(collection string) (map[string]json.RawMessage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	all := map[string]json.RawMessage{}
+	if col, ok := s.collections[collection]; ok {
+		for id, obj := range col.objects {
+			all[id] = obj
+		}
+	}
+	return all, nil
+}
+
+func (s *MemoryStore) Update(collection string, changes *Changes) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	col, ok := s.collections[collection]
+	if !ok || changes.Reset {
+		col = &memoryCollection{objects: map[string]json.RawMessage{}}
+		if ok {
+			col.state = s.collections[collection].state
+		}
+		s.collections[collection] = col
+	}
+	for _, id := range changes.Delete {
+		delete(col.objects, id)
+	}
+	for id, obj := range changes.Put {
+		col.objects[id] = append(json.RawMessage(nil), obj...)
+	}
+	if len(changes.State) > 0 {
+		col.state = changes.State
+	}
+	return nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
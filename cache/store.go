@@ -0,0 +1,25 @@
+package cache
+
+import "encoding/json"
+
+// Store holds cached JMAP objects as the raw JSON the server returned, grouped in collections,
+// one for each data type of each account, along with the state they are in sync with.
+type Store interface {
+	// State returns the state the collection is in sync with, or an empty string when it has none.
+	State(collection string) (string, error)
+	// Get returns the objects of the collection with ids, leaving out those it doesn't hold.
+	Get(collection string, ids []string) (map[string]json.RawMessage, error)
+	// All returns every object the collection holds.
+	All(collection string) (map[string]json.RawMessage, error)
+	// Update applies changes to the collection atomically.
+	Update(collection string, changes *Changes) error
+	Close() error
+}
+
+// Changes are applied to a collection by Store.Update.
+type Changes struct {
+	Reset  bool   // remove every object before applying the other changes
+	State  string // the collection's new state; left unchanged when empty
+	Put    map[string]json.RawMessage
+	Delete []string
+}
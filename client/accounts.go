@@ -0,0 +1,50 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+)
+
+// MailAccountID returns the id of the account that mail operations made with c act on.
+// This is the session's primary mail account unless c was created with WithAccount.
+func (c *Client) MailAccountID() string {
+	if len(c.accountID) > 0 {
+		return c.accountID
+	}
+	if c.Session == nil || c.Session.PrimaryAccounts == nil {
+		return ""
+	}
+	return c.Session.PrimaryAccounts.Mail
+}
+
+// WithAccount returns a copy of c whose operations act on the account with accountID,
+// such as a shared mailbox the token has access to, instead of the primary mail account.
+//
+// The copy shares its session and http client with c.
+func (c *Client) WithAccount(accountID string) (*Client, error) {
+	if c.Session == nil {
+		return nil, fmt.Errorf("client has no session")
+	}
+	acct, ok := c.Session.Accounts[accountID]
+	if !ok {
+		return nil, fmt.Errorf("account `%s` not found in session", accountID)
+	}
+	if acct.AccountCapabilities != nil && acct.AccountCapabilities.Mail == nil {
+		return nil, fmt.Errorf("account `%s` does not support %s", accountID, CapabilityMail)
+	}
+	copied := *c
+	copied.accountID = accountID
+	return &copied, nil
+}
+
+// MailAccounts returns the ids of every account in the session with mail access, sorted.
+func (s *Session) MailAccounts() []string {
+	ids := []string{}
+	for id, acct := range s.Accounts {
+		if acct.AccountCapabilities != nil && acct.AccountCapabilities.Mail != nil {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,40 @@
+package client_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cwinters8/gomap/client"
+	"github.com/cwinters8/gomap/utils"
+)
+
+func TestWithAccount(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(sessionJSON))
+	}))
+	defer srv.Close()
+	c, err := client.NewClient(srv.URL, "token")
+	if err != nil {
+		t.Fatalf("failed to instantiate new client: %s", err.Error())
+	}
+	shared, err := c.WithAccount("u456")
+	if err != nil {
+		t.Fatalf("failed to switch to shared account: %s", err.Error())
+	}
+	_, missingErr := c.WithAccount("u789")
+	accounts := c.Session.MailAccounts()
+	cases := utils.Cases{
+		utils.NewCase(c.MailAccountID() != "u123", "wanted primary mail account u123; got %s", c.MailAccountID()),
+		utils.NewCase(shared.MailAccountID() != "u456", "wanted shared mail account u456; got %s", shared.MailAccountID()),
+		utils.NewCase(shared.Session != c.Session, "wanted shared client to reuse the session"),
+		utils.NewCase(missingErr == nil, "wanted an error for an unknown account"),
+		utils.NewCase(len(accounts) != 2, "wanted 2 mail accounts; got %v", accounts),
+	}
+	if len(accounts) == 2 {
+		cases.Append(utils.NewCase(accounts[0] != "u123" || accounts[1] != "u456", "wanted mail accounts [u123 u456]; got %v", accounts))
+	}
+	cases.Iterator(func(c *utils.Case) {
+		t.Error(c.Message)
+	})
+}
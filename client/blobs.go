@@ -0,0 +1,182 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Blob describes binary data stored on the server (RFC 8620 section 6.1).
+type Blob struct {
+	AccountID string `json:"accountId"`
+	BlobID    string `json:"blobId"`
+	Type      string `json:"type"`
+	Size      int64  `json:"size"`
+}
+
+// Progress is called as data is transferred with the number of bytes transferred so far
+// and the total, which is -1 when unknown.
+type Progress func(transferred, total int64)
+
+// UploadOptions configure Upload.
+type UploadOptions struct {
+	// Size is the length of the data, sent as the Content-Length when known.
+	// The data is sent chunked when Size is zero.
+	Size     int64
+	Progress Progress
+}
+
+// DownloadOptions configure Download.
+type DownloadOptions struct {
+	Name     string // file name the server gives the download; the blob id when empty
+	Type     string // media type the server responds with; application/octet-stream when empty
+	Progress Progress
+}
+
+// Upload streams data to the session's uploadUrl, storing it as a blob in the account
+// with acctID, or the client's mail account when acctID is empty.
+//
+// data is read as it is sent, so it is never held in memory as a whole.
+// For the same reason, uploads are never retried.
+func (c *Client) Upload(ctx context.Context, acctID string, data io.Reader, contentType string, opts *UploadOptions) (blob *Blob, err error) {
+	if c.Session == nil || len(c.Session.UploadURL) < 1 {
+		return nil, fmt.Errorf("session has no upload url")
+	}
+	if opts == nil {
+		opts = &UploadOptions{}
+	}
+	if len(acctID) < 1 {
+		acctID = c.MailAccountID()
+	}
+	if caps := c.Session.Capabilities; caps != nil && caps.Core != nil && caps.Core.MaxSizeUpload > 0 && opts.Size > int64(caps.Core.MaxSizeUpload) {
+		return nil, fmt.Errorf("upload of %d bytes exceeds the server limit of %d bytes", opts.Size, caps.Core.MaxSizeUpload)
+	}
+	url := expandURL(c.Session.UploadURL, map[string]any{"accountId": acctID})
+	total := int64(-1)
+	if opts.Size > 0 {
+		total = opts.Size
+	}
+	body := &progressReader{r: data, total: total, progress: opts.Progress}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload request: %w", err)
+	}
+	req.ContentLength = opts.Size
+	if opts.Size == 0 {
+		req.ContentLength = -1
+	}
+	c.authorize(req)
+	req.Header.Set("Content-Type", contentType)
+
+	x := Exchange{Method: req.Method, URL: url, Header: req.Header, Start: time.Now(), Attempt: 1}
+	defer func() {
+		x.Duration = time.Since(x.Start)
+		x.Err = err
+		c.observe(ctx, &x)
+	}()
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload to %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	x.StatusCode = resp.StatusCode
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload response: %w", err)
+	}
+	x.Response = respBody
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		reqErr := newRequestError(resp.StatusCode, respBody)
+		reqErr.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+		return nil, reqErr
+	}
+	blob = &Blob{}
+	if err := json.Unmarshal(respBody, blob); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal upload response: %w", err)
+	}
+	return blob, nil
+}
+
+// Download streams the blob with blobID from the account with acctID, or the client's
+// mail account when acctID is empty, by expanding the session's downloadUrl template.
+//
+// The caller must close the returned reader. Nothing is read from the server until it is read from.
+func (c *Client) Download(ctx context.Context, acctID, blobID string, opts *DownloadOptions) (body io.ReadCloser, err error) {
+	if c.Session == nil || len(c.Session.DownloadURL) < 1 {
+		return nil, fmt.Errorf("session has no download url")
+	}
+	if opts == nil {
+		opts = &DownloadOptions{}
+	}
+	if len(acctID) < 1 {
+		acctID = c.MailAccountID()
+	}
+	name := opts.Name
+	if len(name) < 1 {
+		name = blobID
+	}
+	contentType := opts.Type
+	if len(contentType) < 1 {
+		contentType = "application/octet-stream"
+	}
+	url := expandURL(c.Session.DownloadURL, map[string]any{
+		"accountId": acctID,
+		"blobId":    blobID,
+		"type":      contentType,
+		"name":      name,
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create download request: %w", err)
+	}
+	c.authorize(req)
+
+	x := Exchange{Method: req.Method, URL: url, Header: req.Header, Start: time.Now(), Attempt: 1}
+	defer func() {
+		// the exchange ends once the response headers arrive, as the body is streamed
+		x.Duration = time.Since(x.Start)
+		x.Err = err
+		c.observe(ctx, &x)
+	}()
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download from %s: %w", url, err)
+	}
+	x.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		reqErr := newRequestError(resp.StatusCode, respBody)
+		reqErr.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+		return nil, reqErr
+	}
+	return &progressReader{r: resp.Body, closer: resp.Body, total: resp.ContentLength, progress: opts.Progress}, nil
+}
+
+// progressReader reports the progress of reading from r.
+type progressReader struct {
+	r        io.Reader
+	closer   io.Closer
+	total    int64
+	read     atomic.Int64
+	progress Progress
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 && p.progress != nil {
+		p.progress(p.read.Add(int64(n)), p.total)
+	}
+	return n, err
+}
+
+func (p *progressReader) Close() error {
+	if p.closer == nil {
+		return nil
+	}
+	return p.closer.Close()
+}
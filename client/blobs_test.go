@@ -0,0 +1,121 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/cwinters8/gomap/client"
+	"github.com/cwinters8/gomap/utils"
+)
+
+func TestBlobs(t *testing.T) {
+	content := strings.Repeat("attachment data ", 4096)
+	var (
+		uploadPath    string
+		uploadType    string
+		uploadLength  int64
+		downloadQuery string
+		downloadPath  string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/upload/"):
+			uploadPath = r.URL.Path
+			uploadType = r.Header.Get("Content-Type")
+			uploadLength = r.ContentLength
+			n, _ := io.Copy(io.Discard, r.Body)
+			w.WriteHeader(http.StatusCreated)
+			io.WriteString(w, `{"accountId":"u123","blobId":"B1","type":"text/plain","size":`+strconv.FormatInt(n, 10)+`}`)
+		case strings.HasPrefix(r.URL.Path, "/download/u123/B1/"):
+			downloadPath = r.URL.EscapedPath()
+			downloadQuery = r.URL.RawQuery
+			io.WriteString(w, content)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	c := &client.Client{
+		Session: &client.Session{
+			PrimaryAccounts: &client.Accounts{Mail: "u123"},
+			UploadURL:       srv.URL + "/upload/{accountId}/",
+			DownloadURL:     srv.URL + "/download/{accountId}/{blobId}/{name}?accept={type}",
+			Capabilities:    &client.Capabilities{Core: &client.CoreCapabilities{MaxSizeUpload: 1 << 20}},
+		},
+		HttpClient: srv.Client(),
+	}
+	ctx := context.Background()
+
+	t.Run("upload", func(t *testing.T) {
+		var last, total int64
+		blob, err := c.Upload(ctx, "", strings.NewReader(content), "text/plain", &client.UploadOptions{
+			Size: int64(len(content)),
+			Progress: func(transferred, size int64) {
+				last, total = transferred, size
+			},
+		})
+		if err != nil {
+			t.Fatalf("upload failure: %s", err.Error())
+		}
+		cases := utils.Cases{
+			utils.NewCase(blob.BlobID != "B1", "wanted blob id B1; got %s", blob.BlobID),
+			utils.NewCase(blob.Size != int64(len(content)), "wanted size %d; got %d", len(content), blob.Size),
+			utils.NewCase(uploadPath != "/upload/u123/", "wanted the mail account in the upload url; got %s", uploadPath),
+			utils.NewCase(uploadType != "text/plain", "wanted content type text/plain; got %s", uploadType),
+			utils.NewCase(uploadLength != int64(len(content)), "wanted content length %d; got %d", len(content), uploadLength),
+			utils.NewCase(last != int64(len(content)) || total != int64(len(content)), "wanted progress to reach %d; got %d of %d", len(content), last, total),
+		}
+		cases.Iterator(func(c *utils.Case) {
+			t.Error(c.Message)
+		})
+	})
+
+	t.Run("upload too large", func(t *testing.T) {
+		_, err := c.Upload(ctx, "", strings.NewReader(""), "text/plain", &client.UploadOptions{Size: 2 << 20})
+		if err == nil {
+			t.Error("wanted an error for an upload over the server limit")
+		}
+	})
+
+	t.Run("download", func(t *testing.T) {
+		var last int64
+		body, err := c.Download(ctx, "", "B1", &client.DownloadOptions{
+			Name: "notes & ideas.txt",
+			Type: "text/plain",
+			Progress: func(transferred, total int64) {
+				last = transferred
+			},
+		})
+		if err != nil {
+			t.Fatalf("download failure: %s", err.Error())
+		}
+		defer body.Close()
+		got, err := io.ReadAll(body)
+		if err != nil {
+			t.Fatalf("failed to read download: %s", err.Error())
+		}
+		cases := utils.Cases{
+			utils.NewCase(string(got) != content, "wanted %d bytes of content; got %d", len(content), len(got)),
+			utils.NewCase(downloadPath != "/download/u123/B1/notes%20%26%20ideas.txt", "unexpected download path %s", downloadPath),
+			utils.NewCase(downloadQuery != "accept=text%2Fplain", "unexpected download query %s", downloadQuery),
+			utils.NewCase(last != int64(len(content)), "wanted progress to reach %d; got %d", len(content), last),
+		}
+		cases.Iterator(func(c *utils.Case) {
+			t.Error(c.Message)
+		})
+	})
+
+	t.Run("download missing", func(t *testing.T) {
+		_, err := c.Download(ctx, "u456", "B1", nil)
+		var reqErr *client.RequestError
+		if !errors.As(err, &reqErr) || reqErr.StatusCode != http.StatusNotFound {
+			t.Errorf("wanted a 404 request error; got %v", err)
+		}
+	})
+}
@@ -2,25 +2,38 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 )
 
 type Client struct {
 	Session    *Session
 	HttpClient *http.Client
+	Retry      *RetryPolicy // retries are disabled when nil
+	Observers  []Observer   // notified after every http request
+	Redaction  *Redaction   // what observers are kept from seeing; the token and email bodies when nil
+	Transport  Transport    // sends API requests in place of http POSTs to Session.APIURL when set
 	token      string
+	accountID  string // overrides the primary mail account when set
 }
 
 func NewClient(sessionURL string, bearerToken string) (*Client, error) {
+	return NewClientWithContext(context.Background(), sessionURL, bearerToken)
+}
+
+// NewClientWithContext is like NewClient, but uses ctx for the session request.
+func NewClientWithContext(ctx context.Context, sessionURL string, bearerToken string) (*Client, error) {
 	c := Client{
 		token:      bearerToken,
 		HttpClient: http.DefaultClient,
 	}
 	// get session
-	status, body, err := c.HttpRequest(http.MethodGet, sessionURL, nil)
+	status, body, err := c.HttpRequestWithContext(ctx, http.MethodGet, sessionURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("request status %d\nfailed to make session request: %w", status, err)
 	}
@@ -35,53 +48,121 @@ func NewClient(sessionURL string, bearerToken string) (*Client, error) {
 	return &c, nil
 }
 
-type Session struct {
-	PrimaryAccounts *Accounts `json:"primaryAccounts"`
-	APIURL          string    `json:"apiURL"`
+// HttpRequest makes an authenticated request to url and returns the response status code and body.
+//
+// A response with a non-2xx status code returns a *RequestError,
+// which can be inspected with errors.As.
+func (c *Client) HttpRequest(method string, url string, body []byte) (int, []byte, error) {
+	return c.HttpRequestWithContext(context.Background(), method, url, body)
 }
 
-type Accounts struct {
-	Core       string `json:"urn:ietf:params:jmap:core"`
-	Mail       string `json:"urn:ietf:params:jmap:mail"`
-	Submission string `json:"urn:ietf:params:jmap:submission"`
+// HttpRequestWithContext is like HttpRequest, but the request is bound to ctx.
+// Cancelling ctx aborts the request in flight.
+//
+// When c.Retry is set, rate limited and temporarily unavailable responses are retried.
+// Connection failures are only retried for GET and HEAD requests.
+func (c *Client) HttpRequestWithContext(ctx context.Context, method string, url string, body []byte) (int, []byte, error) {
+	idempotent := method == http.MethodGet || method == http.MethodHead
+	return c.do(ctx, method, url, body, idempotent)
 }
 
-func (c *Client) HttpRequest(method string, url string, body []byte) (int, []byte, error) {
-	var (
-		req *http.Request
-		err error
-	)
-	if body != nil {
-		req, err = http.NewRequest(method, url, bytes.NewReader(body))
-		if err != nil {
-			return http.StatusInternalServerError, nil, fmt.Errorf("failed to create new request: %w", err)
+// Transport sends JMAP API requests over something other than http POSTs, such as a WebSocket.
+type Transport interface {
+	// Send sends the JMAP Request object body and returns the Response object.
+	// Request-level errors are returned as a *RequestError.
+	Send(ctx context.Context, body []byte) ([]byte, error)
+}
+
+// APIRequest posts body to the session's API URL, or sends it with c.Transport when set.
+//
+// idempotent reports whether the request is safe to send again after
+// a connection failure, in which case c.Retry applies to those failures too.
+func (c *Client) APIRequest(ctx context.Context, body []byte, idempotent bool) (int, []byte, error) {
+	if c.Transport != nil {
+		resp, err := c.Transport.Send(ctx, body)
+		var reqErr *RequestError
+		switch {
+		case errors.As(err, &reqErr):
+			return reqErr.StatusCode, reqErr.Body, err
+		case err != nil:
+			return http.StatusInternalServerError, nil, err
 		}
-	} else {
-		req, err = http.NewRequest(method, url, nil)
-		if err != nil {
-			return http.StatusInternalServerError, nil, fmt.Errorf("failed to create new request: %w", err)
+		return http.StatusOK, resp, nil
+	}
+	if c.Session == nil {
+		return http.StatusInternalServerError, nil, fmt.Errorf("client has no session")
+	}
+	return c.do(ctx, http.MethodPost, c.Session.APIURL, body, idempotent)
+}
+
+func (c *Client) do(ctx context.Context, method string, url string, body []byte, idempotent bool) (status int, respBody []byte, err error) {
+	attempts := c.Retry.attempts()
+	for attempt := 0; attempt < attempts; attempt++ {
+		var (
+			retryAfter time.Duration
+			retry      bool
+		)
+		x := Exchange{
+			Method:  method,
+			URL:     url,
+			Request: body,
+			Start:   time.Now(),
+			Attempt: attempt + 1,
+		}
+		status, respBody, retryAfter, retry, err = c.attempt(ctx, &x, idempotent)
+		x.Duration = time.Since(x.Start)
+		x.Response = respBody
+		x.Err = err
+		c.observe(ctx, &x)
+		if !retry || attempt == attempts-1 {
+			break
+		}
+		if err := c.Retry.Wait(ctx, attempt, retryAfter); err != nil {
+			return status, respBody, fmt.Errorf("stopped retrying after attempt %d: %w", attempt+1, err)
 		}
 	}
-	req.Header = http.Header{
-		"Authorization": []string{
-			fmt.Sprintf("Bearer %s", c.token),
-		},
-		"Content-Type": []string{
-			"application/json",
-		},
+	return status, respBody, err
+}
+
+// attempt makes the single http request described by x and reports whether it is worth retrying.
+// The request headers and response status are recorded in x.
+func (c *Client) attempt(ctx context.Context, x *Exchange, idempotent bool) (status int, respBody []byte, retryAfter time.Duration, retry bool, err error) {
+	var reader io.Reader
+	if x.Request != nil {
+		reader = bytes.NewReader(x.Request)
 	}
+	req, err := http.NewRequestWithContext(ctx, x.Method, x.URL, reader)
+	if err != nil {
+		return http.StatusInternalServerError, nil, 0, false, fmt.Errorf("failed to create new request: %w", err)
+	}
+	c.authorize(req)
+	req.Header.Set("Content-Type", "application/json")
+	x.Header = req.Header
 	resp, err := c.HttpClient.Do(req)
 	if err != nil {
 		status := http.StatusInternalServerError
 		if resp != nil && resp.StatusCode > 0 {
 			status = resp.StatusCode
 		}
-		return status, nil, fmt.Errorf("failed to make %s request to %s: %w", req.Method, req.URL, err)
+		retry := idempotent && ctx.Err() == nil
+		return status, nil, 0, retry, fmt.Errorf("failed to make %s request to %s: %w", req.Method, req.URL, err)
 	}
 	defer resp.Body.Close()
-	respBody, err := io.ReadAll(resp.Body)
+	x.StatusCode = resp.StatusCode
+	respBody, err = io.ReadAll(resp.Body)
 	if err != nil {
-		return resp.StatusCode, body, fmt.Errorf("failed to parse response body: %w", err)
+		retry := idempotent && ctx.Err() == nil
+		return resp.StatusCode, nil, 0, retry, fmt.Errorf("failed to parse response body: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		reqErr := newRequestError(resp.StatusCode, respBody)
+		reqErr.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+		return resp.StatusCode, respBody, reqErr.RetryAfter, retryableStatus(resp.StatusCode), reqErr
 	}
-	return http.StatusOK, respBody, nil
+	return resp.StatusCode, respBody, 0, false, nil
+}
+
+// authorize adds the client's bearer token to req.
+func (c *Client) authorize(req *http.Request) {
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.token))
 }
@@ -1,10 +1,16 @@
 package client_test
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/cwinters8/gomap"
+	"github.com/cwinters8/gomap/client"
 	"github.com/cwinters8/gomap/utils"
 )
 
@@ -51,3 +57,21 @@ func TestNewClient(t *testing.T) {
 		}
 	}
 }
+
+func TestHttpRequestWithContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// block until the client gives up on the request
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+	c := client.Client{HttpClient: srv.Client()}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, _, err := c.HttpRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if err == nil {
+		t.Fatal("wanted an error from a cancelled request")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("wanted error to wrap context.DeadlineExceeded; got %v", err)
+	}
+}
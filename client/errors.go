@@ -0,0 +1,67 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ProblemType identifies a JMAP request-level error (RFC 8620 section 3.6.1).
+type ProblemType string
+
+const (
+	ProblemUnknownCapability ProblemType = "urn:ietf:params:jmap:error:unknownCapability"
+	ProblemNotJSON           ProblemType = "urn:ietf:params:jmap:error:notJSON"
+	ProblemNotRequest        ProblemType = "urn:ietf:params:jmap:error:notRequest"
+	ProblemLimit             ProblemType = "urn:ietf:params:jmap:error:limit"
+)
+
+// RequestError is returned for any response with a non-2xx status code.
+//
+// When the server responds with an RFC 7807 problem details document,
+// the Type, Title, Detail and Limit fields are populated from it.
+// Otherwise only StatusCode, Body and RetryAfter are set.
+type RequestError struct {
+	StatusCode int           `json:"-"`
+	Type       ProblemType   `json:"type"`
+	Status     int           `json:"status"`
+	Title      string        `json:"title"`
+	Detail     string        `json:"detail"`
+	Limit      string        `json:"limit"` // name of the exceeded limit when Type is ProblemLimit
+	Body       []byte        `json:"-"`
+	RetryAfter time.Duration `json:"-"` // parsed from the Retry-After header, if the server sent one
+}
+
+func (e *RequestError) Error() string {
+	if len(e.Type) > 0 {
+		msg := fmt.Sprintf("status %d - request error `%s`", e.StatusCode, e.Type)
+		if len(e.Limit) > 0 {
+			msg += fmt.Sprintf(" on limit `%s`", e.Limit)
+		}
+		if len(e.Detail) > 0 {
+			msg += ": " + e.Detail
+		}
+		return msg
+	}
+	body := strings.TrimSpace(string(e.Body))
+	if len(body) > 200 {
+		body = body[:200] + "..."
+	}
+	if len(body) > 0 {
+		return fmt.Sprintf("status %d - unexpected response: %s", e.StatusCode, body)
+	}
+	return fmt.Sprintf("status %d - unexpected response", e.StatusCode)
+}
+
+// newRequestError builds a RequestError from a non-2xx response,
+// decoding problem details from body when present.
+func newRequestError(status int, body []byte) *RequestError {
+	e := RequestError{}
+	if err := json.Unmarshal(body, &e); err != nil || len(e.Type) == 0 {
+		e = RequestError{}
+	}
+	e.StatusCode = status
+	e.Body = body
+	return &e
+}
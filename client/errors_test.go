@@ -0,0 +1,78 @@
+package client_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cwinters8/gomap/client"
+	"github.com/cwinters8/gomap/utils"
+)
+
+func TestRequestError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/limit":
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{
+				"type": "urn:ietf:params:jmap:error:limit",
+				"limit": "maxSizeRequest",
+				"status": 400,
+				"detail": "The request is larger than the server is willing to process."
+			}`))
+		case "/unauthorized":
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte("Authorization header not a valid format"))
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer srv.Close()
+	c := client.Client{HttpClient: srv.Client()}
+
+	t.Run("problem details", func(t *testing.T) {
+		status, _, err := c.HttpRequest(http.MethodPost, srv.URL+"/limit", []byte(`{}`))
+		var reqErr *client.RequestError
+		if !errors.As(err, &reqErr) {
+			t.Fatalf("wanted a *client.RequestError; got %v", err)
+		}
+		cases := utils.Cases{
+			utils.NewCase(status != http.StatusBadRequest, "wanted status %d; got %d", http.StatusBadRequest, status),
+			utils.NewCase(reqErr.StatusCode != http.StatusBadRequest, "wanted error status %d; got %d", http.StatusBadRequest, reqErr.StatusCode),
+			utils.NewCase(reqErr.Type != client.ProblemLimit, "wanted problem type %s; got %s", client.ProblemLimit, reqErr.Type),
+			utils.NewCase(reqErr.Limit != "maxSizeRequest", "wanted limit maxSizeRequest; got %s", reqErr.Limit),
+			utils.NewCase(len(reqErr.Detail) == 0, "wanted detail to be populated"),
+		}
+		cases.Iterator(func(c *utils.Case) {
+			t.Error(c.Message)
+		})
+	})
+
+	t.Run("plain status", func(t *testing.T) {
+		status, _, err := c.HttpRequest(http.MethodGet, srv.URL+"/unauthorized", nil)
+		var reqErr *client.RequestError
+		if !errors.As(err, &reqErr) {
+			t.Fatalf("wanted a *client.RequestError; got %v", err)
+		}
+		cases := utils.Cases{
+			utils.NewCase(status != http.StatusUnauthorized, "wanted status %d; got %d", http.StatusUnauthorized, status),
+			utils.NewCase(len(reqErr.Type) > 0, "wanted empty problem type; got %s", reqErr.Type),
+			utils.NewCase(string(reqErr.Body) != "Authorization header not a valid format", "unexpected body %s", reqErr.Body),
+		}
+		cases.Iterator(func(c *utils.Case) {
+			t.Error(c.Message)
+		})
+	})
+
+	t.Run("success", func(t *testing.T) {
+		status, body, err := c.HttpRequest(http.MethodGet, srv.URL, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err.Error())
+		}
+		if status != http.StatusOK || string(body) != "{}" {
+			t.Errorf("wanted status 200 and body {}; got %d and %s", status, body)
+		}
+	})
+}
@@ -0,0 +1,264 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StateChange reports the new state of every data type that changed on the server (RFC 8620 section 7.1).
+type StateChange struct {
+	Type    string                       `json:"@type"`   // always "StateChange"
+	Changed map[string]map[string]string `json:"changed"` // account id to data type, e.g. "Email", to its new state
+	// PushState identifies the change on a WebSocket, for resuming with WebSocket.EnablePush.
+	PushState string `json:"pushState"`
+}
+
+// State returns the new state of dataType in the account with acctID,
+// or false when it didn't change.
+func (s *StateChange) State(acctID, dataType string) (string, bool) {
+	state, ok := s.Changed[acctID][dataType]
+	return state, ok
+}
+
+// CloseAfter controls when the server ends an event source stream.
+type CloseAfter string
+
+const (
+	CloseAfterNo    CloseAfter = "no"    // keep the stream open
+	CloseAfterState CloseAfter = "state" // end the stream after the first state change, for long polling
+)
+
+// EventSourceOptions configure Listen and Events.
+type EventSourceOptions struct {
+	Types      []string      // data types to be notified about, e.g. "Email"; every type when empty
+	CloseAfter CloseAfter    // CloseAfterNo when empty
+	Ping       time.Duration // how often the server should send a ping; no pings when zero
+}
+
+// defaultReconnect is used to wait before reconnecting when the client has no retry policy.
+var defaultReconnect = RetryPolicy{BaseDelay: time.Second, MaxDelay: time.Minute}
+
+// Listen opens the session's event source (RFC 8620 section 7.3) and calls fn with every
+// StateChange the server pushes, until ctx is done or fn returns an error.
+//
+// Dropped connections are reopened with the Last-Event-ID header, so no change is missed,
+// waiting between attempts according to c.Retry or the delay the server asks for.
+// When Ping is set, a connection that goes quiet for twice that long is considered dropped.
+//
+// Listen always returns a non-nil error: ctx.Err() once ctx is done, the error from fn,
+// or a *RequestError when the server refuses the stream.
+func (c *Client) Listen(ctx context.Context, opts *EventSourceOptions, fn func(*StateChange) error) error {
+	if c.Session == nil || len(c.Session.EventSourceURL) < 1 {
+		return fmt.Errorf("session has no event source url")
+	}
+	if opts == nil {
+		opts = &EventSourceOptions{}
+	}
+	types := opts.Types
+	if len(types) < 1 {
+		types = []string{"*"}
+	}
+	closeAfter := opts.CloseAfter
+	if len(closeAfter) < 1 {
+		closeAfter = CloseAfterNo
+	}
+	url := expandURL(c.Session.EventSourceURL, map[string]any{
+		"types":      types,
+		"closeafter": string(closeAfter),
+		"ping":       strconv.Itoa(int(opts.Ping.Seconds())),
+	})
+	reconnect := defaultReconnect
+	if c.Retry != nil {
+		reconnect = *c.Retry
+	}
+	s := stream{client: c, url: url, ping: opts.Ping, fn: fn}
+	failures := 0
+	for {
+		delivered, err := s.open(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var reqErr *RequestError
+		var fnErr *listenerError
+		switch {
+		case errors.As(err, &fnErr):
+			return fnErr.err
+		case errors.As(err, &reqErr) && !retryableStatus(reqErr.StatusCode):
+			return err
+		}
+		if delivered {
+			failures = 0
+		}
+		delay := reconnect.Backoff(failures)
+		if s.retry > 0 {
+			delay = s.retry
+		}
+		if reqErr != nil && reqErr.RetryAfter > 0 {
+			delay = reqErr.RetryAfter
+		}
+		failures++
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Events is like Listen, but delivers state changes on a channel.
+// Once listening stops, the reason is sent on the error channel and both channels are closed.
+func (c *Client) Events(ctx context.Context, opts *EventSourceOptions) (<-chan *StateChange, <-chan error) {
+	changes := make(chan *StateChange)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(changes)
+		defer close(errs)
+		errs <- c.Listen(ctx, opts, func(s *StateChange) error {
+			select {
+			case changes <- s:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+	return changes, errs
+}
+
+// stream is a single event source connection, remembering what's needed to resume it.
+type stream struct {
+	client      *Client
+	url         string
+	ping        time.Duration
+	fn          func(*StateChange) error
+	lastEventID string
+	retry       time.Duration // reconnection delay requested by the server
+}
+
+// listenerError wraps an error returned by the callback, so it isn't mistaken for a dropped connection.
+type listenerError struct {
+	err error
+}
+
+func (e *listenerError) Error() string {
+	return e.err.Error()
+}
+
+// open connects to the event source and reads events until the stream ends,
+// reporting whether any state change was delivered.
+func (s *stream) open(ctx context.Context) (delivered bool, err error) {
+	connCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	x := Exchange{Method: http.MethodGet, URL: s.url, Start: time.Now(), Attempt: 1}
+	defer func() {
+		x.Duration = time.Since(x.Start)
+		x.Err = err
+		s.client.observe(ctx, &x)
+	}()
+	req, err := http.NewRequestWithContext(connCtx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create event source request: %w", err)
+	}
+	s.client.authorize(req)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	if len(s.lastEventID) > 0 {
+		req.Header.Set("Last-Event-ID", s.lastEventID)
+	}
+	x.Header = req.Header
+	resp, err := s.client.HttpClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to connect to event source: %w", err)
+	}
+	defer resp.Body.Close()
+	x.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var body [4096]byte
+		n, _ := resp.Body.Read(body[:])
+		reqErr := newRequestError(resp.StatusCode, body[:n])
+		reqErr.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+		return false, reqErr
+	}
+
+	// without a ping the server may legitimately stay quiet forever
+	var watchdog *time.Timer
+	if s.ping > 0 {
+		watchdog = time.AfterFunc(2*s.ping+5*time.Second, cancel)
+		defer watchdog.Stop()
+	}
+	var (
+		event string
+		data  strings.Builder
+	)
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if watchdog != nil {
+			watchdog.Reset(2*s.ping + 5*time.Second)
+		}
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if len(line) < 1 {
+			// a blank line dispatches the event
+			if data.Len() > 0 && (event == "state" || event == "") {
+				change, err := parseStateChange(data.String())
+				if err != nil {
+					return delivered, err
+				}
+				if change != nil {
+					if err := s.fn(change); err != nil {
+						return delivered, &listenerError{err}
+					}
+					delivered = true
+				}
+			}
+			event = ""
+			data.Reset()
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+		case "id":
+			s.lastEventID = value
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil {
+				s.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return delivered, fmt.Errorf("event source stream failed: %w", err)
+	}
+	return delivered, nil
+}
+
+// parseStateChange decodes the data of an event, returning nil for anything other than a StateChange.
+func parseStateChange(data string) (*StateChange, error) {
+	var change StateChange
+	if err := json.Unmarshal([]byte(data), &change); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal state change: %w", err)
+	}
+	if change.Type != "StateChange" {
+		return nil, nil
+	}
+	return &change, nil
+}
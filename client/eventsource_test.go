@@ -0,0 +1,122 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cwinters8/gomap/client"
+	"github.com/cwinters8/gomap/utils"
+)
+
+func TestListen(t *testing.T) {
+	var (
+		connections atomic.Int32
+		query       atomic.Value
+		lastEventID atomic.Value
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		n := connections.Add(1)
+		query.Store(r.URL.RawQuery)
+		lastEventID.Store(r.Header.Get("Last-Event-ID"))
+		w.Header().Set("Content-Type", "text/event-stream")
+		// the first connection drops after a single change, so the client has to resume
+		fmt.Fprint(w, "retry: 1\n\n: comment\n\nevent: ping\ndata: {\"interval\":30}\n\n")
+		fmt.Fprintf(w, "event: state\nid: %d\ndata: {\"@type\":\"StateChange\",\n", n)
+		fmt.Fprintf(w, "data: \"changed\":{\"u123\":{\"Email\":\"s%d\"}}}\n\n", n)
+		w.(http.Flusher).Flush()
+		if n > 1 {
+			<-r.Context().Done()
+		}
+	}))
+	defer srv.Close()
+	sessionSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(sessionJSON))
+	}))
+	defer sessionSrv.Close()
+	c, err := client.NewClient(sessionSrv.URL, "token")
+	if err != nil {
+		t.Fatalf("failed to create client: %s", err.Error())
+	}
+	c.Session.EventSourceURL = srv.URL + "/events?types={types}&closeafter={closeafter}&ping={ping}"
+
+	t.Run("resumes", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		var states []string
+		err := c.Listen(ctx, &client.EventSourceOptions{
+			Types: []string{"Email", "Mailbox"},
+			Ping:  30 * time.Second,
+		}, func(s *client.StateChange) error {
+			state, _ := s.State("u123", "Email")
+			states = append(states, state)
+			if len(states) == 2 {
+				cancel()
+			}
+			return nil
+		})
+		cases := utils.Cases{
+			utils.NewCase(!errors.Is(err, context.Canceled), "wanted listening to stop when cancelled; got %v", err),
+			utils.NewCase(len(states) != 2 || states[0] != "s1" || states[1] != "s2", "wanted states s1 and s2; got %v", states),
+			utils.NewCase(lastEventID.Load() != "1", "wanted Last-Event-ID 1 on reconnect; got %v", lastEventID.Load()),
+			utils.NewCase(query.Load() != "types=Email,Mailbox&closeafter=no&ping=30", "unexpected query %v", query.Load()),
+		}
+		cases.Iterator(func(c *utils.Case) {
+			t.Error(c.Message)
+		})
+	})
+
+	t.Run("callback error", func(t *testing.T) {
+		want := errors.New("stop")
+		err := c.Listen(context.Background(), nil, func(*client.StateChange) error {
+			return want
+		})
+		if !errors.Is(err, want) {
+			t.Errorf("wanted the callback's error; got %v", err)
+		}
+		if query.Load() != "types=%2A&closeafter=no&ping=0" {
+			t.Errorf("unexpected default query %v", query.Load())
+		}
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		other, err := client.NewClient(sessionSrv.URL, "wrong")
+		if err != nil {
+			t.Fatalf("failed to create client: %s", err.Error())
+		}
+		other.Session.EventSourceURL = srv.URL
+		err = other.Listen(context.Background(), nil, func(*client.StateChange) error {
+			return nil
+		})
+		var reqErr *client.RequestError
+		if !errors.As(err, &reqErr) || reqErr.StatusCode != http.StatusUnauthorized {
+			t.Errorf("wanted a 401 request error; got %v", err)
+		}
+	})
+
+	t.Run("events", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		changes, errs := c.Events(ctx, nil)
+		change := <-changes
+		cancel()
+		for range changes {
+		}
+		err := <-errs
+		cases := utils.Cases{
+			utils.NewCase(change == nil || change.Type != "StateChange", "wanted a state change; got %v", change),
+			utils.NewCase(!errors.Is(err, context.Canceled), "wanted context.Canceled; got %v", err),
+		}
+		cases.Iterator(func(c *utils.Case) {
+			t.Error(c.Message)
+		})
+	})
+}
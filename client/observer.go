@@ -0,0 +1,195 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Exchange describes a single http request made by a Client and its outcome.
+// Retried requests produce one Exchange per attempt.
+type Exchange struct {
+	Method     string
+	URL        string
+	Header     http.Header // request headers
+	Request    []byte      // request body; nil when there was none or it was streamed
+	StatusCode int         // zero when no response was received
+	Response   []byte      // response body; nil when there was none or it was streamed
+	Start      time.Time
+	Duration   time.Duration
+	Attempt    int // starting at 1
+	Err        error
+}
+
+// Observer is notified after every http request a Client makes.
+//
+// Observers are called synchronously, so a slow observer slows down the client.
+// Exchanges are redacted according to the client's Redaction before observers see them.
+type Observer interface {
+	Observe(ctx context.Context, x *Exchange)
+}
+
+// ObserverFunc adapts a function to the Observer interface.
+type ObserverFunc func(ctx context.Context, x *Exchange)
+
+func (f ObserverFunc) Observe(ctx context.Context, x *Exchange) {
+	f(ctx, x)
+}
+
+// Redaction controls what is hidden from observers.
+// The bearer token and email bodies are always hidden unless kept explicitly,
+// so the zero value, which a Client with a nil Redaction uses, hides only those.
+type Redaction struct {
+	KeepToken      bool     // show the bearer token in the Authorization header
+	KeepBodyValues bool     // show the contents of every email's bodyValues
+	Keys           []string // hide the value of any other JSON object key with one of these names, e.g. "subject"
+}
+
+const redacted = "[REDACTED]"
+
+// Apply returns a copy of x with the configured fields redacted.
+func (r Redaction) Apply(x *Exchange) *Exchange {
+	copied := *x
+	if !r.KeepToken && x.Header != nil {
+		copied.Header = x.Header.Clone()
+		if len(copied.Header.Get("Authorization")) > 0 {
+			copied.Header.Set("Authorization", "Bearer "+redacted)
+		}
+	}
+	if !r.KeepBodyValues || len(r.Keys) > 0 {
+		copied.Request = r.redactBody(x.Request)
+		copied.Response = r.redactBody(x.Response)
+	}
+	return &copied
+}
+
+func (r Redaction) redactBody(body []byte) []byte {
+	if len(body) == 0 {
+		return body
+	}
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		// not json, so the body can't be inspected safely
+		return []byte(redacted)
+	}
+	keys := map[string]bool{}
+	for _, k := range r.Keys {
+		keys[k] = true
+	}
+	if !r.redactValue(v, keys) {
+		// leave untouched bodies byte for byte as they were sent
+		return body
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return []byte(redacted)
+	}
+	return b
+}
+
+// redactValue redacts v in place and reports whether anything was redacted.
+func (r Redaction) redactValue(v any, keys map[string]bool) bool {
+	changed := false
+	switch val := v.(type) {
+	case map[string]any:
+		for k, child := range val {
+			switch {
+			case keys[k]:
+				val[k] = redacted
+				changed = true
+			case !r.KeepBodyValues && k == "bodyValues":
+				if parts, ok := child.(map[string]any); ok {
+					for _, part := range parts {
+						if p, ok := part.(map[string]any); ok {
+							p["value"] = redacted
+							changed = true
+						}
+					}
+				}
+			default:
+				changed = r.redactValue(child, keys) || changed
+			}
+		}
+	case []any:
+		for _, child := range val {
+			changed = r.redactValue(child, keys) || changed
+		}
+	}
+	return changed
+}
+
+// observe hands x to every observer of c, after redaction.
+func (c *Client) observe(ctx context.Context, x *Exchange) {
+	if len(c.Observers) < 1 {
+		return
+	}
+	var redaction Redaction
+	if c.Redaction != nil {
+		redaction = *c.Redaction
+	}
+	x = redaction.Apply(x)
+	for _, o := range c.Observers {
+		o.Observe(ctx, x)
+	}
+}
+
+// NewSlogObserver returns an Observer that logs every exchange to logger at level.
+// Failed exchanges are logged at slog.LevelWarn or above.
+// Request and response bodies are only included when includeBodies is true.
+func NewSlogObserver(logger *slog.Logger, level slog.Level, includeBodies bool) Observer {
+	return ObserverFunc(func(ctx context.Context, x *Exchange) {
+		attrs := []slog.Attr{
+			slog.String("method", x.Method),
+			slog.String("url", x.URL),
+			slog.Int("status", x.StatusCode),
+			slog.Duration("duration", x.Duration),
+			slog.Int("attempt", x.Attempt),
+			slog.Int("request_bytes", len(x.Request)),
+			slog.Int("response_bytes", len(x.Response)),
+		}
+		if includeBodies {
+			attrs = append(attrs,
+				slog.String("request", string(x.Request)),
+				slog.String("response", string(x.Response)),
+			)
+		}
+		lvl := level
+		if x.Err != nil {
+			attrs = append(attrs, slog.String("error", x.Err.Error()))
+			if lvl < slog.LevelWarn {
+				lvl = slog.LevelWarn
+			}
+		}
+		logger.LogAttrs(ctx, lvl, "jmap http exchange", attrs...)
+	})
+}
+
+// Recorder is an Observer that keeps every exchange in memory,
+// which is useful in tests and when debugging.
+type Recorder struct {
+	mu        sync.Mutex
+	exchanges []*Exchange
+}
+
+func (r *Recorder) Observe(ctx context.Context, x *Exchange) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.exchanges = append(r.exchanges, x)
+}
+
+// Exchanges returns the exchanges recorded so far.
+func (r *Recorder) Exchanges() []*Exchange {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*Exchange{}, r.exchanges...)
+}
+
+// Reset discards the recorded exchanges.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.exchanges = nil
+}
@@ -0,0 +1,133 @@
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cwinters8/gomap/client"
+	"github.com/cwinters8/gomap/utils"
+)
+
+func TestObserver(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/session" {
+			w.Write([]byte(sessionJSON))
+			return
+		}
+		if hits.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"methodResponses":[["Email/get",{"list":[{"id":"M1","subject":"hello","bodyValues":{"1":{"value":"secret body"}}}]},"c1"]]}`))
+	}))
+	defer srv.Close()
+	c, err := client.NewClient(srv.URL+"/session", "top-secret-token")
+	if err != nil {
+		t.Fatalf("failed to create client: %s", err.Error())
+	}
+	c.Session.APIURL = srv.URL + "/api"
+	c.Retry = &client.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+	request := []byte(`{"using":[],"methodCalls":[["Email/get",{"ids":["M1"]},"c1"]]}`)
+
+	t.Run("default redaction", func(t *testing.T) {
+		hits.Store(0)
+		var rec client.Recorder
+		c.Observers = []client.Observer{&rec}
+		if _, _, err := c.APIRequest(context.Background(), request, true); err != nil {
+			t.Fatalf("request failure: %s", err.Error())
+		}
+		exchanges := rec.Exchanges()
+		if len(exchanges) != 2 {
+			t.Fatalf("wanted an exchange for each of 2 attempts; got %d", len(exchanges))
+		}
+		first, last := exchanges[0], exchanges[1]
+		cases := utils.Cases{
+			utils.NewCase(first.Attempt != 1 || last.Attempt != 2, "wanted attempts 1 and 2; got %d and %d", first.Attempt, last.Attempt),
+			utils.NewCase(first.StatusCode != http.StatusServiceUnavailable, "wanted first status 503; got %d", first.StatusCode),
+			utils.NewCase(first.Err == nil, "wanted first exchange to carry the error"),
+			utils.NewCase(last.StatusCode != http.StatusOK, "wanted last status 200; got %d", last.StatusCode),
+			utils.NewCase(last.Method != http.MethodPost, "wanted method POST; got %s", last.Method),
+			utils.NewCase(last.URL != c.Session.APIURL, "wanted url %s; got %s", c.Session.APIURL, last.URL),
+			utils.NewCase(!bytes.Equal(last.Request, request), "wanted request body %s; got %s", request, last.Request),
+			utils.NewCase(last.Duration <= 0, "wanted a positive duration; got %s", last.Duration),
+			utils.NewCase(strings.Contains(last.Header.Get("Authorization"), "top-secret-token"), "wanted token redacted; got %s", last.Header.Get("Authorization")),
+			utils.NewCase(bytes.Contains(last.Response, []byte("secret body")), "wanted body values redacted; got %s", last.Response),
+			utils.NewCase(!bytes.Contains(last.Response, []byte("hello")), "wanted subject kept; got %s", last.Response),
+		}
+		cases.Iterator(func(c *utils.Case) {
+			t.Error(c.Message)
+		})
+	})
+
+	t.Run("custom redaction", func(t *testing.T) {
+		hits.Store(1)
+		var rec client.Recorder
+		c.Observers = []client.Observer{&rec}
+		c.Redaction = &client.Redaction{Keys: []string{"subject"}}
+		defer func() { c.Redaction = nil }()
+		if _, _, err := c.APIRequest(context.Background(), request, true); err != nil {
+			t.Fatalf("request failure: %s", err.Error())
+		}
+		x := rec.Exchanges()[0]
+		cases := utils.Cases{
+			utils.NewCase(strings.Contains(x.Header.Get("Authorization"), "top-secret-token"), "wanted token still redacted; got %s", x.Header.Get("Authorization")),
+			utils.NewCase(bytes.Contains(x.Response, []byte("secret body")), "wanted body values still redacted; got %s", x.Response),
+			utils.NewCase(bytes.Contains(x.Response, []byte("hello")), "wanted subject redacted; got %s", x.Response),
+		}
+		cases.Iterator(func(c *utils.Case) {
+			t.Error(c.Message)
+		})
+	})
+
+	t.Run("kept", func(t *testing.T) {
+		hits.Store(1)
+		var rec client.Recorder
+		c.Observers = []client.Observer{&rec}
+		c.Redaction = &client.Redaction{KeepToken: true, KeepBodyValues: true}
+		defer func() { c.Redaction = nil }()
+		if _, _, err := c.APIRequest(context.Background(), request, true); err != nil {
+			t.Fatalf("request failure: %s", err.Error())
+		}
+		x := rec.Exchanges()[0]
+		cases := utils.Cases{
+			utils.NewCase(!strings.Contains(x.Header.Get("Authorization"), "top-secret-token"), "wanted token kept; got %s", x.Header.Get("Authorization")),
+			utils.NewCase(!bytes.Contains(x.Response, []byte("secret body")), "wanted body values kept; got %s", x.Response),
+		}
+		cases.Iterator(func(c *utils.Case) {
+			t.Error(c.Message)
+		})
+	})
+
+	t.Run("slog", func(t *testing.T) {
+		hits.Store(1)
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, nil))
+		c.Observers = []client.Observer{client.NewSlogObserver(logger, slog.LevelInfo, false)}
+		if _, _, err := c.APIRequest(context.Background(), request, true); err != nil {
+			t.Fatalf("request failure: %s", err.Error())
+		}
+		var entry map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+			t.Fatalf("failed to unmarshal log entry %s: %s", buf.String(), err.Error())
+		}
+		_, hasBody := entry["response"]
+		cases := utils.Cases{
+			utils.NewCase(entry["method"] != http.MethodPost, "wanted method POST; got %v", entry["method"]),
+			utils.NewCase(entry["status"] != float64(http.StatusOK), "wanted status 200; got %v", entry["status"]),
+			utils.NewCase(entry["attempt"] != float64(1), "wanted attempt 1; got %v", entry["attempt"]),
+			utils.NewCase(hasBody, "wanted bodies left out of the log entry"),
+		}
+		cases.Iterator(func(c *utils.Case) {
+			t.Error(c.Message)
+		})
+	})
+}
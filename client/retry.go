@@ -0,0 +1,101 @@
+package client
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how a Client retries requests that were rate limited,
+// hit a temporarily unavailable server, or lost their connection.
+//
+// Responses with status 429 or 503 are always retried, since the server did not
+// process the request. Connection failures are only retried for idempotent requests,
+// because the server may have processed the request before the connection dropped.
+type RetryPolicy struct {
+	MaxAttempts int           // total number of attempts, including the first
+	BaseDelay   time.Duration // delay before the first retry; doubled for each retry after that
+	MaxDelay    time.Duration // upper bound on any single backoff; a server's Retry-After is waited out in full
+}
+
+// DefaultRetryPolicy is a reasonable starting point for batch jobs.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    30 * time.Second,
+}
+
+// Backoff returns the delay before retry number attempt, starting at 0.
+// The delay grows exponentially and is jittered so that concurrent clients spread out.
+func (p *RetryPolicy) Backoff(attempt int) time.Duration {
+	d := p.BaseDelay
+	for i := 0; i < attempt && (p.MaxDelay <= 0 || d < p.MaxDelay); i++ {
+		if d > math.MaxInt64/2 {
+			// doubling again would overflow
+			d = math.MaxInt64
+			break
+		}
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	// equal jitter: wait somewhere between half and all of d
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(d-half)+1))
+}
+
+// Wait blocks until the delay for retry number attempt has passed or ctx is done.
+// retryAfter, when greater than the computed backoff, is used instead, even beyond MaxDelay,
+// since retrying any sooner would only be refused again; bound ctx to limit how long that is.
+func (p *RetryPolicy) Wait(ctx context.Context, attempt int, retryAfter time.Duration) error {
+	d := p.Backoff(attempt)
+	if retryAfter > d {
+		d = retryAfter
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// attempts returns the number of attempts allowed by p, which may be nil.
+func (p *RetryPolicy) attempts() int {
+	if p == nil || p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+func retryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable
+}
+
+// parseRetryAfter reads a Retry-After header given in either seconds or as an HTTP date.
+func parseRetryAfter(value string) time.Duration {
+	if len(value) == 0 {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
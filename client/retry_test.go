@@ -0,0 +1,158 @@
+package client_test
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cwinters8/gomap/client"
+	"github.com/cwinters8/gomap/utils"
+)
+
+func TestRetry(t *testing.T) {
+	policy := &client.RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    10 * time.Millisecond,
+	}
+
+	t.Run("rate limited", func(t *testing.T) {
+		var hits atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if hits.Add(1) < 3 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.Write([]byte(`{}`))
+		}))
+		defer srv.Close()
+		c := client.Client{
+			Session:    &client.Session{APIURL: srv.URL},
+			HttpClient: srv.Client(),
+			Retry:      policy,
+		}
+		status, _, err := c.APIRequest(context.Background(), []byte(`{}`), false)
+		if err != nil {
+			t.Fatalf("wanted request to succeed after retrying; got %s", err.Error())
+		}
+		cases := utils.Cases{
+			utils.NewCase(status != http.StatusOK, "wanted status 200; got %d", status),
+			utils.NewCase(hits.Load() != 3, "wanted 3 attempts; got %d", hits.Load()),
+		}
+		cases.Iterator(func(c *utils.Case) {
+			t.Error(c.Message)
+		})
+	})
+
+	t.Run("gives up", func(t *testing.T) {
+		var hits atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits.Add(1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer srv.Close()
+		c := client.Client{HttpClient: srv.Client(), Retry: policy}
+		status, _, err := c.HttpRequest(http.MethodGet, srv.URL, nil)
+		if err == nil {
+			t.Fatal("wanted an error after exhausting retries")
+		}
+		cases := utils.Cases{
+			utils.NewCase(status != http.StatusServiceUnavailable, "wanted status 503; got %d", status),
+			utils.NewCase(hits.Load() != 3, "wanted 3 attempts; got %d", hits.Load()),
+		}
+		cases.Iterator(func(c *utils.Case) {
+			t.Error(c.Message)
+		})
+	})
+
+	t.Run("connection reset", func(t *testing.T) {
+		var hits atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits.Add(1)
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Errorf("failed to hijack connection: %s", err.Error())
+				return
+			}
+			conn.Close()
+		}))
+		defer srv.Close()
+		c := client.Client{
+			Session:    &client.Session{APIURL: srv.URL},
+			HttpClient: srv.Client(),
+			Retry:      policy,
+		}
+		if _, _, err := c.APIRequest(context.Background(), []byte(`{}`), false); err == nil {
+			t.Fatal("wanted an error from a dropped connection")
+		}
+		if got := hits.Load(); got != 1 {
+			t.Errorf("wanted non-idempotent request to be attempted once; got %d", got)
+		}
+		hits.Store(0)
+		if _, _, err := c.APIRequest(context.Background(), []byte(`{}`), true); err == nil {
+			t.Fatal("wanted an error from a dropped connection")
+		}
+		if got := hits.Load(); got != 3 {
+			t.Errorf("wanted idempotent request to be attempted 3 times; got %d", got)
+		}
+	})
+}
+
+func TestBackoff(t *testing.T) {
+	policy := client.RetryPolicy{
+		BaseDelay: 100 * time.Millisecond,
+		MaxDelay:  time.Second,
+	}
+	for attempt, max := range []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	} {
+		d := policy.Backoff(attempt)
+		if d < max/2 || d > max {
+			t.Errorf("attempt %d: wanted backoff between %s and %s; got %s", attempt, max/2, max, d)
+		}
+	}
+}
+
+func TestWaitRetryAfter(t *testing.T) {
+	policy := client.RetryPolicy{BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+	start := time.Now()
+	if err := policy.Wait(context.Background(), 0, 100*time.Millisecond); err != nil {
+		t.Fatalf("failed to wait: %s", err.Error())
+	}
+	if waited := time.Since(start); waited < 100*time.Millisecond {
+		t.Errorf("wanted Retry-After to be waited out beyond MaxDelay; waited %s", waited)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := policy.Wait(ctx, 0, time.Hour); err != context.DeadlineExceeded {
+		t.Errorf("wanted ctx to cut a long Retry-After short; got %v", err)
+	}
+}
+
+func TestBackoffUncapped(t *testing.T) {
+	policy := client.RetryPolicy{BaseDelay: 100 * time.Millisecond}
+	for attempt, max := range []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+	} {
+		d := policy.Backoff(attempt)
+		if d < max/2 || d > max {
+			t.Errorf("attempt %d: wanted backoff between %s and %s; got %s", attempt, max/2, max, d)
+		}
+	}
+	if d := policy.Backoff(1000); d < math.MaxInt64/2 {
+		t.Errorf("wanted a long attempt count to saturate rather than overflow; got %s", d)
+	}
+}
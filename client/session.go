@@ -0,0 +1,195 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// capability URIs used as keys in the session resource
+const (
+	CapabilityCore       = "urn:ietf:params:jmap:core"
+	CapabilityMail       = "urn:ietf:params:jmap:mail"
+	CapabilitySubmission = "urn:ietf:params:jmap:submission"
+	CapabilityWebSocket  = "urn:ietf:params:jmap:websocket"
+)
+
+// Session is the JMAP session resource described in RFC 8620 section 2.
+type Session struct {
+	Capabilities    *Capabilities       `json:"capabilities"`
+	Accounts        map[string]*Account `json:"accounts"`
+	PrimaryAccounts *Accounts           `json:"primaryAccounts"`
+	Username        string              `json:"username"`
+	APIURL          string              `json:"apiUrl"`
+	DownloadURL     string              `json:"downloadUrl"`
+	UploadURL       string              `json:"uploadUrl"`
+	EventSourceURL  string              `json:"eventSourceUrl"`
+	State           string              `json:"state"`
+}
+
+// Accounts holds the primary account id for each capability.
+type Accounts struct {
+	Core       string `json:"urn:ietf:params:jmap:core"`
+	Mail       string `json:"urn:ietf:params:jmap:mail"`
+	Submission string `json:"urn:ietf:params:jmap:submission"`
+}
+
+// Capabilities describes the capabilities supported by the server.
+//
+// Capabilities without a dedicated field are kept as raw JSON in Other,
+// keyed by their URI.
+type Capabilities struct {
+	Core       *CoreCapabilities
+	Mail       *struct{}
+	Submission *struct{}
+	WebSocket  *WebSocketCapabilities
+	Other      map[string]json.RawMessage
+}
+
+// WebSocketCapabilities describe the server's JMAP over WebSocket endpoint (RFC 8887 section 4).
+type WebSocketCapabilities struct {
+	URL          string `json:"url"`
+	SupportsPush bool   `json:"supportsPush"`
+}
+
+// CoreCapabilities are the server limits advertised under urn:ietf:params:jmap:core.
+type CoreCapabilities struct {
+	MaxSizeUpload         int      `json:"maxSizeUpload"`
+	MaxConcurrentUpload   int      `json:"maxConcurrentUpload"`
+	MaxSizeRequest        int      `json:"maxSizeRequest"`
+	MaxConcurrentRequests int      `json:"maxConcurrentRequests"`
+	MaxCallsInRequest     int      `json:"maxCallsInRequest"`
+	MaxObjectsInGet       int      `json:"maxObjectsInGet"`
+	MaxObjectsInSet       int      `json:"maxObjectsInSet"`
+	CollationAlgorithms   []string `json:"collationAlgorithms"`
+}
+
+func (c *Capabilities) UnmarshalJSON(b []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return fmt.Errorf("failed to unmarshal capabilities to map: %w", err)
+	}
+	caps := Capabilities{}
+	for k, v := range raw {
+		var err error
+		switch k {
+		case CapabilityCore:
+			err = json.Unmarshal(v, &caps.Core)
+		case CapabilityMail:
+			caps.Mail = &struct{}{}
+		case CapabilitySubmission:
+			caps.Submission = &struct{}{}
+		case CapabilityWebSocket:
+			err = json.Unmarshal(v, &caps.WebSocket)
+		default:
+			if caps.Other == nil {
+				caps.Other = map[string]json.RawMessage{}
+			}
+			caps.Other[k] = v
+		}
+		if err != nil {
+			return fmt.Errorf("failed to unmarshal capability `%s`: %w", k, err)
+		}
+	}
+	*c = caps
+	return nil
+}
+
+func (c Capabilities) MarshalJSON() ([]byte, error) {
+	raw := map[string]any{}
+	for k, v := range c.Other {
+		raw[k] = v
+	}
+	if c.Core != nil {
+		raw[CapabilityCore] = c.Core
+	}
+	if c.Mail != nil {
+		raw[CapabilityMail] = c.Mail
+	}
+	if c.Submission != nil {
+		raw[CapabilitySubmission] = c.Submission
+	}
+	if c.WebSocket != nil {
+		raw[CapabilityWebSocket] = c.WebSocket
+	}
+	return json.Marshal(raw)
+}
+
+// Account is an entry in the session's accounts map.
+type Account struct {
+	Name                string               `json:"name"`
+	IsPersonal          bool                 `json:"isPersonal"`
+	IsReadOnly          bool                 `json:"isReadOnly"`
+	AccountCapabilities *AccountCapabilities `json:"accountCapabilities"`
+}
+
+// AccountCapabilities describes the capabilities available to a single account.
+//
+// As with Capabilities, any capability without a dedicated field is kept in Other.
+type AccountCapabilities struct {
+	Core       *struct{}
+	Mail       *MailCapabilities
+	Submission *SubmissionCapabilities
+	Other      map[string]json.RawMessage
+}
+
+// MailCapabilities are the account level limits of urn:ietf:params:jmap:mail (RFC 8621 section 1.3.1).
+type MailCapabilities struct {
+	MaxMailboxesPerEmail       *int     `json:"maxMailboxesPerEmail"`
+	MaxMailboxDepth            *int     `json:"maxMailboxDepth"`
+	MaxSizeMailboxName         int      `json:"maxSizeMailboxName"`
+	MaxSizeAttachmentsPerEmail int      `json:"maxSizeAttachmentsPerEmail"`
+	EmailQuerySortOptions      []string `json:"emailQuerySortOptions"`
+	MayCreateTopLevelMailbox   bool     `json:"mayCreateTopLevelMailbox"`
+}
+
+// SubmissionCapabilities are the account level properties of urn:ietf:params:jmap:submission (RFC 8621 section 1.3.2).
+type SubmissionCapabilities struct {
+	MaxDelayedSend       int                 `json:"maxDelayedSend"`
+	SubmissionExtensions map[string][]string `json:"submissionExtensions"`
+}
+
+func (c *AccountCapabilities) UnmarshalJSON(b []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return fmt.Errorf("failed to unmarshal account capabilities to map: %w", err)
+	}
+	caps := AccountCapabilities{}
+	for k, v := range raw {
+		var err error
+		switch k {
+		case CapabilityCore:
+			caps.Core = &struct{}{}
+		case CapabilityMail:
+			err = json.Unmarshal(v, &caps.Mail)
+		case CapabilitySubmission:
+			err = json.Unmarshal(v, &caps.Submission)
+		default:
+			if caps.Other == nil {
+				caps.Other = map[string]json.RawMessage{}
+			}
+			caps.Other[k] = v
+		}
+		if err != nil {
+			return fmt.Errorf("failed to unmarshal account capability `%s`: %w", k, err)
+		}
+	}
+	*c = caps
+	return nil
+}
+
+func (c AccountCapabilities) MarshalJSON() ([]byte, error) {
+	raw := map[string]any{}
+	for k, v := range c.Other {
+		raw[k] = v
+	}
+	if c.Core != nil {
+		raw[CapabilityCore] = c.Core
+	}
+	if c.Mail != nil {
+		raw[CapabilityMail] = c.Mail
+	}
+	if c.Submission != nil {
+		raw[CapabilitySubmission] = c.Submission
+	}
+	return json.Marshal(raw)
+}
@@ -0,0 +1,124 @@
+package client_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cwinters8/gomap/client"
+	"github.com/cwinters8/gomap/utils"
+)
+
+const sessionJSON = `{
+	"capabilities": {
+		"urn:ietf:params:jmap:core": {
+			"maxSizeUpload": 50000000,
+			"maxConcurrentUpload": 4,
+			"maxSizeRequest": 10000000,
+			"maxConcurrentRequests": 4,
+			"maxCallsInRequest": 16,
+			"maxObjectsInGet": 500,
+			"maxObjectsInSet": 500,
+			"collationAlgorithms": ["i;ascii-numeric", "i;ascii-casemap"]
+		},
+		"urn:ietf:params:jmap:mail": {},
+		"urn:ietf:params:jmap:submission": {},
+		"https://www.fastmail.com/dev/maskedemail": {}
+	},
+	"accounts": {
+		"u123": {
+			"name": "gopher@example.com",
+			"isPersonal": true,
+			"isReadOnly": false,
+			"accountCapabilities": {
+				"urn:ietf:params:jmap:core": {},
+				"urn:ietf:params:jmap:mail": {
+					"maxMailboxesPerEmail": null,
+					"maxMailboxDepth": 10,
+					"maxSizeMailboxName": 490,
+					"maxSizeAttachmentsPerEmail": 50000000,
+					"emailQuerySortOptions": ["receivedAt", "subject"],
+					"mayCreateTopLevelMailbox": true
+				},
+				"urn:ietf:params:jmap:submission": {
+					"maxDelayedSend": 44236800,
+					"submissionExtensions": {"SIZE": ["70000000"]}
+				}
+			}
+		},
+		"u456": {
+			"name": "team@example.com",
+			"isPersonal": false,
+			"isReadOnly": true,
+			"accountCapabilities": {
+				"urn:ietf:params:jmap:mail": {}
+			}
+		}
+	},
+	"primaryAccounts": {
+		"urn:ietf:params:jmap:core": "u123",
+		"urn:ietf:params:jmap:mail": "u123",
+		"urn:ietf:params:jmap:submission": "u123"
+	},
+	"username": "gopher@example.com",
+	"apiUrl": "https://jmap.example.com/api/",
+	"downloadUrl": "https://jmap.example.com/download/{accountId}/{blobId}/{name}?type={type}",
+	"uploadUrl": "https://jmap.example.com/upload/{accountId}/",
+	"eventSourceUrl": "https://jmap.example.com/eventsource/?types={types}&closeafter={closeafter}&ping={ping}",
+	"state": "cyrus-0;p-5"
+}`
+
+func TestSession(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(sessionJSON))
+	}))
+	defer srv.Close()
+	c, err := client.NewClient(srv.URL, "token")
+	if err != nil {
+		t.Fatalf("failed to instantiate new client: %s", err.Error())
+	}
+	sess := c.Session
+	fatals := utils.Cases{
+		utils.NewCase(sess.Capabilities == nil, "capabilities should not be nil"),
+		utils.NewCase(sess.Capabilities != nil && sess.Capabilities.Core == nil, "core capabilities should not be nil"),
+		utils.NewCase(sess.Accounts["u123"] == nil, "account u123 should not be nil"),
+		utils.NewCase(sess.Accounts["u456"] == nil, "account u456 should not be nil"),
+	}
+	failed := 0
+	fatals.Iterator(func(c *utils.Case) {
+		t.Error(c.Message)
+		failed++
+	})
+	if failed > 0 {
+		t.FailNow()
+	}
+	core := sess.Capabilities.Core
+	personal := sess.Accounts["u123"]
+	shared := sess.Accounts["u456"]
+	cases := utils.Cases{
+		utils.NewCase(core.MaxCallsInRequest != 16, "wanted maxCallsInRequest 16; got %d", core.MaxCallsInRequest),
+		utils.NewCase(core.MaxObjectsInGet != 500, "wanted maxObjectsInGet 500; got %d", core.MaxObjectsInGet),
+		utils.NewCase(core.MaxSizeRequest != 10000000, "wanted maxSizeRequest 10000000; got %d", core.MaxSizeRequest),
+		utils.NewCase(len(core.CollationAlgorithms) != 2, "wanted 2 collation algorithms; got %d", len(core.CollationAlgorithms)),
+		utils.NewCase(sess.Capabilities.Mail == nil, "mail capability should be present"),
+		utils.NewCase(sess.Capabilities.Other["https://www.fastmail.com/dev/maskedemail"] == nil, "unknown capability should be kept in Other"),
+		utils.NewCase(personal.Name != "gopher@example.com", "wanted account name gopher@example.com; got %s", personal.Name),
+		utils.NewCase(!personal.IsPersonal, "wanted account u123 to be personal"),
+		utils.NewCase(personal.AccountCapabilities.Mail.MaxMailboxDepth == nil, "wanted maxMailboxDepth to be set"),
+		utils.NewCase(personal.AccountCapabilities.Mail.MaxMailboxesPerEmail != nil, "wanted maxMailboxesPerEmail to be nil"),
+		utils.NewCase(personal.AccountCapabilities.Submission.MaxDelayedSend != 44236800, "wanted maxDelayedSend 44236800; got %d", personal.AccountCapabilities.Submission.MaxDelayedSend),
+		utils.NewCase(!shared.IsReadOnly, "wanted account u456 to be read only"),
+		utils.NewCase(shared.AccountCapabilities.Submission != nil, "wanted account u456 to lack submission"),
+		utils.NewCase(sess.PrimaryAccounts.Mail != "u123", "wanted primary mail account u123; got %s", sess.PrimaryAccounts.Mail),
+		utils.NewCase(sess.Username != "gopher@example.com", "wanted username gopher@example.com; got %s", sess.Username),
+		utils.NewCase(sess.APIURL != "https://jmap.example.com/api/", "wanted api url https://jmap.example.com/api/; got %s", sess.APIURL),
+		utils.NewCase(sess.UploadURL != "https://jmap.example.com/upload/{accountId}/", "unexpected upload url %s", sess.UploadURL),
+		utils.NewCase(sess.DownloadURL == "", "download url should not be empty"),
+		utils.NewCase(sess.EventSourceURL == "", "event source url should not be empty"),
+		utils.NewCase(sess.State != "cyrus-0;p-5", "wanted state cyrus-0;p-5; got %s", sess.State),
+	}
+	cases.Iterator(func(c *utils.Case) {
+		t.Error(c.Message)
+	})
+}
@@ -0,0 +1,57 @@
+package client
+
+import (
+	"strings"
+)
+
+// expandURL expands the simple {name} expressions of a level 1 URI template (RFC 6570),
+// as used by the session's downloadUrl, uploadUrl and eventSourceUrl.
+//
+// A []string value is expanded as a comma separated list.
+// Variables missing from vars expand to an empty string.
+func expandURL(template string, vars map[string]any) string {
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(template, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(template[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+		b.WriteString(template[:start])
+		switch v := vars[template[start+1:end]].(type) {
+		case string:
+			b.WriteString(escapeTemplateValue(v))
+		case []string:
+			for i, s := range v {
+				if i > 0 {
+					b.WriteByte(',')
+				}
+				b.WriteString(escapeTemplateValue(s))
+			}
+		}
+		template = template[end+1:]
+	}
+	b.WriteString(template)
+	return b.String()
+}
+
+// escapeTemplateValue percent-encodes every byte of s outside the unreserved set.
+func escapeTemplateValue(s string) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || '0' <= ch && ch <= '9' || strings.IndexByte("-._~", ch) >= 0 {
+			b.WriteByte(ch)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hex[ch>>4])
+		b.WriteByte(hex[ch&15])
+	}
+	return b.String()
+}
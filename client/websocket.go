@@ -0,0 +1,262 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket is a JMAP over WebSocket connection (RFC 8887).
+//
+// It implements Transport, so setting it as a Client's Transport sends every API request
+// made with the client, including those of the requests and objects packages, over the connection.
+// Requests are multiplexed, so it is safe to use from several goroutines at once.
+//
+//	ws, err := c.DialWebSocket(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	defer ws.Close()
+//	c.Transport = ws
+type WebSocket struct {
+	client    *Client
+	url       string
+	conn      *websocket.Conn
+	writeMu   sync.Mutex
+	mu        sync.Mutex
+	pending   map[string]chan wsResult
+	onChange  func(*StateChange)
+	requestID atomic.Uint64
+	done      chan struct{}
+	err       error // why the connection closed, set before done is closed
+}
+
+type wsResult struct {
+	body []byte
+	err  error
+}
+
+// wsMessage holds the fields common to every message the server sends.
+type wsMessage struct {
+	Type      string `json:"@type"`
+	RequestID string `json:"requestId"`
+	Status    int    `json:"status"`
+}
+
+// DialWebSocket connects to the WebSocket endpoint advertised in the session's capabilities.
+func (c *Client) DialWebSocket(ctx context.Context) (*WebSocket, error) {
+	if c.Session == nil || c.Session.Capabilities == nil || c.Session.Capabilities.WebSocket == nil {
+		return nil, fmt.Errorf("server does not support %s", CapabilityWebSocket)
+	}
+	url := c.Session.Capabilities.WebSocket.URL
+	dialer := websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: 45 * time.Second,
+		Subprotocols:     []string{"jmap"},
+	}
+	if c.HttpClient != nil {
+		dialer.Jar = c.HttpClient.Jar
+		if t, ok := c.HttpClient.Transport.(*http.Transport); ok {
+			dialer.TLSClientConfig = t.TLSClientConfig
+		}
+	}
+	header := http.Header{}
+	header.Set("Authorization", fmt.Sprintf("Bearer %s", c.token))
+	conn, resp, err := dialer.DialContext(ctx, url, header)
+	if err != nil {
+		if resp != nil {
+			return nil, fmt.Errorf("failed to open websocket: %w", newRequestError(resp.StatusCode, nil))
+		}
+		return nil, fmt.Errorf("failed to open websocket: %w", err)
+	}
+	if conn.Subprotocol() != "jmap" {
+		conn.Close()
+		return nil, fmt.Errorf("server did not accept the jmap websocket subprotocol")
+	}
+	ws := WebSocket{
+		client:  c,
+		url:     url,
+		conn:    conn,
+		pending: map[string]chan wsResult{},
+		done:    make(chan struct{}),
+	}
+	go ws.read()
+	return &ws, nil
+}
+
+// Send sends a JMAP Request object over the connection and waits for its Response.
+func (ws *WebSocket) Send(ctx context.Context, body []byte) (resp []byte, err error) {
+	var req map[string]json.RawMessage
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal request: %w", err)
+	}
+	id := "r" + strconv.FormatUint(ws.requestID.Add(1), 36)
+	req["@type"] = json.RawMessage(`"Request"`)
+	req["id"], _ = json.Marshal(id)
+	msg, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	x := Exchange{Method: "WEBSOCKET", URL: ws.url, Request: msg, Start: time.Now(), Attempt: 1}
+	defer func() {
+		x.Duration = time.Since(x.Start)
+		x.Response = resp
+		x.Err = err
+		ws.client.observe(ctx, &x)
+	}()
+
+	result := make(chan wsResult, 1)
+	ws.mu.Lock()
+	ws.pending[id] = result
+	ws.mu.Unlock()
+	defer func() {
+		ws.mu.Lock()
+		delete(ws.pending, id)
+		ws.mu.Unlock()
+	}()
+	if err := ws.write(ctx, msg); err != nil {
+		return nil, err
+	}
+	select {
+	case r := <-result:
+		return r.body, r.err
+	case <-ws.done:
+		return nil, fmt.Errorf("websocket closed before response to request %s: %w", id, ws.err)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// EnablePush asks the server to push StateChange objects for dataTypes over the connection,
+// calling fn with each of them. Every data type is pushed when dataTypes is nil.
+//
+// When pushState is the PushState of the last change received on an earlier connection,
+// the server first sends any changes made since then.
+//
+// fn is called from the goroutine reading the connection, so responses
+// are held up until it returns.
+func (ws *WebSocket) EnablePush(ctx context.Context, dataTypes []string, pushState string, fn func(*StateChange)) error {
+	ws.mu.Lock()
+	ws.onChange = fn
+	ws.mu.Unlock()
+	msg := map[string]any{
+		"@type":     "WebSocketPushEnable",
+		"dataTypes": dataTypes,
+	}
+	if len(pushState) > 0 {
+		msg["pushState"] = pushState
+	}
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal push enable message: %w", err)
+	}
+	return ws.write(ctx, b)
+}
+
+// DisablePush stops the server from pushing state changes over the connection.
+func (ws *WebSocket) DisablePush(ctx context.Context) error {
+	if err := ws.write(ctx, []byte(`{"@type":"WebSocketPushDisable"}`)); err != nil {
+		return err
+	}
+	ws.mu.Lock()
+	ws.onChange = nil
+	ws.mu.Unlock()
+	return nil
+}
+
+// Done is closed once the connection has closed, after which Err reports why.
+func (ws *WebSocket) Done() <-chan struct{} {
+	return ws.done
+}
+
+// Err returns the reason the connection closed, or nil while it is open.
+func (ws *WebSocket) Err() error {
+	select {
+	case <-ws.done:
+		return ws.err
+	default:
+		return nil
+	}
+}
+
+// Close closes the connection. Requests waiting for a response fail.
+func (ws *WebSocket) Close() error {
+	ws.writeMu.Lock()
+	ws.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+	ws.writeMu.Unlock()
+	err := ws.conn.Close()
+	<-ws.done
+	return err
+}
+
+func (ws *WebSocket) write(ctx context.Context, msg []byte) error {
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Time{}
+	}
+	ws.conn.SetWriteDeadline(deadline)
+	if err := ws.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+		return fmt.Errorf("failed to write websocket message: %w", err)
+	}
+	return nil
+}
+
+// read dispatches incoming messages until the connection closes.
+func (ws *WebSocket) read() {
+	var err error
+	defer func() {
+		ws.err = err
+		close(ws.done)
+	}()
+	for {
+		var b []byte
+		_, b, err = ws.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var msg wsMessage
+		if jsonErr := json.Unmarshal(b, &msg); jsonErr != nil {
+			continue
+		}
+		switch msg.Type {
+		case "Response":
+			ws.deliver(msg.RequestID, wsResult{body: b})
+		case "RequestError":
+			status := msg.Status
+			if status == 0 {
+				status = http.StatusBadRequest
+			}
+			ws.deliver(msg.RequestID, wsResult{err: newRequestError(status, b)})
+		case "StateChange":
+			var change StateChange
+			if jsonErr := json.Unmarshal(b, &change); jsonErr != nil {
+				continue
+			}
+			ws.mu.Lock()
+			fn := ws.onChange
+			ws.mu.Unlock()
+			if fn != nil {
+				fn(&change)
+			}
+		}
+	}
+}
+
+func (ws *WebSocket) deliver(id string, r wsResult) {
+	ws.mu.Lock()
+	result, ok := ws.pending[id]
+	ws.mu.Unlock()
+	if ok {
+		result <- r
+	}
+}
@@ -0,0 +1,180 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cwinters8/gomap/client"
+	"github.com/cwinters8/gomap/utils"
+	"github.com/gorilla/websocket"
+)
+
+func TestWebSocket(t *testing.T) {
+	upgrader := websocket.Upgrader{Subprotocols: []string{"jmap"}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("failed to upgrade: %s", err.Error())
+			return
+		}
+		defer conn.Close()
+		var mu sync.Mutex
+		for {
+			var msg map[string]any
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			switch msg["@type"] {
+			case "Request":
+				// answer out of order to show responses are matched by request id
+				go func(msg map[string]any) {
+					calls := msg["methodCalls"].([]any)
+					resp := map[string]any{
+						"@type":     "Response",
+						"requestId": msg["id"],
+					}
+					if len(calls) == 0 {
+						resp = map[string]any{
+							"@type":     "RequestError",
+							"requestId": msg["id"],
+							"type":      string(client.ProblemNotRequest),
+							"status":    400,
+						}
+					} else {
+						call := calls[0].([]any)
+						if call[0] == "Slow/get" {
+							time.Sleep(50 * time.Millisecond)
+						}
+						resp["methodResponses"] = []any{[]any{call[0], map[string]any{}, call[2]}}
+					}
+					mu.Lock()
+					defer mu.Unlock()
+					conn.WriteJSON(resp)
+				}(msg)
+			case "WebSocketPushEnable":
+				mu.Lock()
+				conn.WriteJSON(map[string]any{
+					"@type":     "StateChange",
+					"changed":   map[string]any{"u123": map[string]string{"Email": "s2"}},
+					"pushState": "p1",
+				})
+				mu.Unlock()
+			}
+		}
+	}))
+	defer srv.Close()
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http")
+	c := &client.Client{
+		Session: &client.Session{
+			Capabilities: &client.Capabilities{
+				WebSocket: &client.WebSocketCapabilities{URL: wsURL, SupportsPush: true},
+			},
+		},
+		HttpClient: srv.Client(),
+	}
+	sessionSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(sessionJSON))
+	}))
+	defer sessionSrv.Close()
+	authed, err := client.NewClient(sessionSrv.URL, "token")
+	if err != nil {
+		t.Fatalf("failed to create client: %s", err.Error())
+	}
+	authed.Session.Capabilities = c.Session.Capabilities
+	ctx := context.Background()
+
+	t.Run("unauthorized", func(t *testing.T) {
+		_, err := c.DialWebSocket(ctx)
+		var reqErr *client.RequestError
+		if !errors.As(err, &reqErr) || reqErr.StatusCode != http.StatusUnauthorized {
+			t.Errorf("wanted a 401 request error; got %v", err)
+		}
+	})
+
+	ws, err := authed.DialWebSocket(ctx)
+	if err != nil {
+		t.Fatalf("failed to dial: %s", err.Error())
+	}
+	authed.Transport = ws
+	var rec client.Recorder
+	authed.Observers = []client.Observer{&rec}
+
+	t.Run("multiplexed", func(t *testing.T) {
+		var wg sync.WaitGroup
+		results := make([]string, 2)
+		for i, method := range []string{"Slow/get", "Fast/get"} {
+			wg.Add(1)
+			go func(i int, method string) {
+				defer wg.Done()
+				body := `{"using":[],"methodCalls":[["` + method + `",{},"c1"]]}`
+				status, resp, err := authed.APIRequest(ctx, []byte(body), true)
+				if err != nil || status != http.StatusOK {
+					t.Errorf("request failure with status %d: %v", status, err)
+					return
+				}
+				var r struct {
+					MethodResponses [][3]any `json:"methodResponses"`
+				}
+				json.Unmarshal(resp, &r)
+				if len(r.MethodResponses) > 0 {
+					results[i], _ = r.MethodResponses[0][0].(string)
+				}
+			}(i, method)
+		}
+		wg.Wait()
+		cases := utils.Cases{
+			utils.NewCase(results[0] != "Slow/get" || results[1] != "Fast/get", "wanted each response matched to its request; got %v", results),
+			utils.NewCase(len(rec.Exchanges()) != 2, "wanted 2 observed exchanges; got %d", len(rec.Exchanges())),
+		}
+		cases.Iterator(func(c *utils.Case) {
+			t.Error(c.Message)
+		})
+	})
+
+	t.Run("request error", func(t *testing.T) {
+		status, _, err := authed.APIRequest(ctx, []byte(`{"using":[],"methodCalls":[]}`), true)
+		var reqErr *client.RequestError
+		if !errors.As(err, &reqErr) || reqErr.Type != client.ProblemNotRequest || status != http.StatusBadRequest {
+			t.Errorf("wanted a notRequest request error with status 400; got %d %v", status, err)
+		}
+	})
+
+	t.Run("push", func(t *testing.T) {
+		changes := make(chan *client.StateChange, 1)
+		if err := ws.EnablePush(ctx, []string{"Email"}, "", func(s *client.StateChange) {
+			changes <- s
+		}); err != nil {
+			t.Fatalf("failed to enable push: %s", err.Error())
+		}
+		select {
+		case change := <-changes:
+			state, _ := change.State("u123", "Email")
+			if state != "s2" || change.PushState != "p1" {
+				t.Errorf("unexpected state change %v", change)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for state change")
+		}
+	})
+
+	t.Run("close", func(t *testing.T) {
+		ws.Close()
+		if _, _, err := authed.APIRequest(ctx, []byte(`{"using":[],"methodCalls":[]}`), true); err == nil {
+			t.Error("wanted requests to fail after closing")
+		}
+		if ws.Err() == nil {
+			t.Error("wanted the close reason to be reported")
+		}
+	})
+}
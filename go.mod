@@ -1,7 +1,14 @@
 module github.com/cwinters8/gomap
 
-go 1.19
+go 1.21
 
 require github.com/joho/godotenv v1.4.0
 
 require github.com/google/uuid v1.3.0
+
+require (
+	github.com/gorilla/websocket v1.5.3
+	go.etcd.io/bbolt v1.3.10
+)
+
+require golang.org/x/sys v0.4.0 // indirect
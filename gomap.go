@@ -1,6 +1,7 @@
 package gomap
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -13,31 +14,86 @@ type Client struct {
 	*client.Client
 	Drafts *mailboxes.Mailbox
 	Sent   *mailboxes.Mailbox
+	// Mailboxes with other special roles, found by role alone. Each is nil when the account has none.
+	Inbox   *mailboxes.Mailbox
+	Trash   *mailboxes.Mailbox
+	Junk    *mailboxes.Mailbox
+	Archive *mailboxes.Mailbox
+	// PollInterval is how often GetEmails repeats its query, even while the server pushes changes.
+	// DefaultPollInterval is used when it is zero.
+	PollInterval time.Duration
 }
 
 // NewClient creates a new JMAP mail client that can be used for interacting
 // with the JMAP mail server specified with jmapSessionURL.
 //
-// Commonly, draftsMailbox should be "Drafts" and sentMailbox should be "Sent".
-// These arguments are available in case customization is necessary.
-// You can use the DefaultDrafts and DefaultSent constants for convenience.
+// The drafts and sent mailboxes are found by their RFC 8621 roles, which works however
+// they are named, so draftsMailbox and sentMailbox should usually be empty, as
+// DefaultDrafts and DefaultSent are. A non-empty name overrides the role and selects
+// the mailbox with that name instead.
+//
+// When a mailbox is found by role and the account has none with that role,
+// the error is a *mailboxes.RoleNotFoundError naming the role.
 func NewClient(jmapSessionURL, bearerToken, draftsMailbox, sentMailbox string) (*Client, error) {
-	client, err := client.NewClient(jmapSessionURL, bearerToken)
+	return NewClientWithContext(context.Background(), jmapSessionURL, bearerToken, draftsMailbox, sentMailbox)
+}
+
+// NewClientWithContext is like NewClient, but the session and mailbox requests are bound to ctx.
+func NewClientWithContext(ctx context.Context, jmapSessionURL, bearerToken, draftsMailbox, sentMailbox string) (*Client, error) {
+	client, err := client.NewClientWithContext(ctx, jmapSessionURL, bearerToken)
 	if err != nil {
 		return nil, fmt.Errorf("failed to instantiate jmap client: %w", err)
 	}
-	drafts, err := mailboxes.GetMailboxByName(client, draftsMailbox)
+	return newClient(ctx, client, draftsMailbox, sentMailbox)
+}
+
+// WithAccount returns a copy of c that acts on the account with accountID,
+// such as a shared team account, instead of the primary mail account.
+// draftsMailbox and sentMailbox are looked up in that account.
+//
+// The ids of every account the token can reach are available from c.Session.Accounts.
+func (c *Client) WithAccount(accountID, draftsMailbox, sentMailbox string) (*Client, error) {
+	return c.WithAccountWithContext(context.Background(), accountID, draftsMailbox, sentMailbox)
+}
+
+// WithAccountWithContext is like WithAccount, but the mailbox requests are bound to ctx.
+func (c *Client) WithAccountWithContext(ctx context.Context, accountID, draftsMailbox, sentMailbox string) (*Client, error) {
+	client, err := c.Client.WithAccount(accountID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve drafts mailbox: %w", err)
+		return nil, fmt.Errorf("failed to switch account: %w", err)
 	}
-	sent, err := mailboxes.GetMailboxByName(client, sentMailbox)
+	return newClient(ctx, client, draftsMailbox, sentMailbox)
+}
+
+func newClient(ctx context.Context, client *client.Client, draftsMailbox, sentMailbox string) (*Client, error) {
+	boxes, _, err := mailboxes.GetMailboxesWithContext(ctx, client, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve mailboxes: %w", err)
+	}
+	drafts, err := findMailbox(boxes, mailboxes.RoleDrafts, draftsMailbox)
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve sent mailbox: %w", err)
+		return nil, fmt.Errorf("failed to find drafts mailbox: %w", err)
 	}
-	c := Client{client, drafts, sent}
+	sent, err := findMailbox(boxes, mailboxes.RoleSent, sentMailbox)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find sent mailbox: %w", err)
+	}
+	c := Client{Client: client, Drafts: drafts, Sent: sent}
+	c.Inbox, _ = mailboxes.FindByRole(boxes, mailboxes.RoleInbox)
+	c.Trash, _ = mailboxes.FindByRole(boxes, mailboxes.RoleTrash)
+	c.Junk, _ = mailboxes.FindByRole(boxes, mailboxes.RoleJunk)
+	c.Archive, _ = mailboxes.FindByRole(boxes, mailboxes.RoleArchive)
 	return &c, nil
 }
 
+// findMailbox finds the mailbox named name, or the one with role when name is empty.
+func findMailbox(boxes []*mailboxes.Mailbox, role mailboxes.Role, name string) (*mailboxes.Mailbox, error) {
+	if len(name) > 0 {
+		return mailboxes.FindByName(boxes, name)
+	}
+	return mailboxes.FindByRole(boxes, role)
+}
+
 // SendEmail sends an email using the provided arguments.
 //
 // Addresses in from must be owned by the account authenticated with the Client,
@@ -46,6 +102,11 @@ func NewClient(jmapSessionURL, bearerToken, draftsMailbox, sentMailbox string) (
 // Setting isHTML to true will set the body type attribute to HTML instead of plaintext.
 // This works best if body is a string that has been output from executing an html/template.
 func (c *Client) SendEmail(from, to Addresses, subject, body string, isHTML bool) error {
+	return c.SendEmailWithContext(context.Background(), from, to, subject, body, isHTML)
+}
+
+// SendEmailWithContext is like SendEmail, but the requests are bound to ctx.
+func (c *Client) SendEmailWithContext(ctx context.Context, from, to Addresses, subject, body string, isHTML bool) error {
 	bodyType := emails.TextPlain
 	if isHTML {
 		bodyType = emails.TextHTML
@@ -54,10 +115,10 @@ func (c *Client) SendEmail(from, to Addresses, subject, body string, isHTML bool
 	if err != nil {
 		return fmt.Errorf("failed to instantiate new email: %w", err)
 	}
-	if err := emails.Set(c.Client, []*emails.Email{email}); err != nil {
+	if err := emails.SetWithContext(ctx, c.Client, []*emails.Email{email}); err != nil {
 		return fmt.Errorf("email set request failure: %w", err)
 	}
-	if _, err := email.Submit(c.Client, c.Drafts.ID, c.Sent.ID); err != nil {
+	if _, err := email.SubmitWithContext(ctx, c.Client, c.Drafts.ID, c.Sent.ID); err != nil {
 		return fmt.Errorf("failed to submit email: %w", err)
 	}
 	return nil
@@ -66,28 +127,71 @@ func (c *Client) SendEmail(from, to Addresses, subject, body string, isHTML bool
 // GetEmails retrieves emails based on the provided filter.
 // It will continue to query until the first of maxCount or timeout has been reached.
 //
+// The query is repeated every c.PollInterval, and sooner when the server pushes
+// a change to emails.
+//
 // maxCount is used as a metric for breaking out of the query loop,
 // not a hard limit on the number of emails returned.
 func (c *Client) GetEmails(filter *Filter, maxCount int, timeout time.Duration) ([]*emails.Email, error) {
+	return c.GetEmailsWithContext(context.Background(), filter, maxCount, timeout)
+}
+
+// DefaultPollInterval is how often GetEmails repeats its query when Client.PollInterval is zero.
+const DefaultPollInterval = 2 * time.Second
+
+// GetEmailsWithContext is like GetEmails, but the requests are bound to ctx.
+// Cancelling ctx stops the query loop before timeout is reached.
+func (c *Client) GetEmailsWithContext(ctx context.Context, filter *Filter, maxCount int, timeout time.Duration) ([]*emails.Email, error) {
 	var emailIDs []string
 	f := emails.Filter(*filter)
-	end := time.Now().Add(timeout)
-	for time.Now().Compare(end) < 1 {
-		newIDs, err := emails.Query(c.Client, &f)
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	interval := c.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	var (
+		changes  <-chan *client.StateChange
+		pushErrs <-chan error
+	)
+	if c.Session != nil && len(c.Session.EventSourceURL) > 0 {
+		changes, pushErrs = c.Events(waitCtx, &client.EventSourceOptions{Types: []string{"Email"}})
+	}
+query:
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("stopped querying for emails: %w", err)
+		}
+		newIDs, err := emails.QueryWithContext(ctx, c.Client, &f)
 		if err != nil {
 			return nil, fmt.Errorf("failed to query for emails: %w", err)
 		}
-		if len(newIDs) > 0 {
-			emailIDs = append(emailIDs, newIDs...)
-		}
+		// each query returns every matching email, so the latest result replaces the previous one
+		emailIDs = newIDs
 		if len(emailIDs) >= maxCount {
 			break
 		}
+		// polling continues while push is active, in case a change is never pushed
+		poll := time.NewTimer(interval)
+		select {
+		case <-waitCtx.Done():
+			poll.Stop()
+			break query
+		case _, ok := <-changes:
+			if !ok {
+				// push stopped working, so rely on polling alone
+				changes, pushErrs = nil, nil
+			}
+		case <-pushErrs:
+			changes, pushErrs = nil, nil
+		case <-poll.C:
+		}
+		poll.Stop()
 	}
 	if len(emailIDs) == 0 {
 		return nil, fmt.Errorf("email IDs matching provided filter not found")
 	}
-	found, notFound, err := emails.GetEmails(c.Client, emailIDs)
+	found, notFound, err := emails.GetEmailsWithContext(ctx, c.Client, emailIDs)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve emails: %w", err)
 	}
@@ -102,14 +206,20 @@ func (c *Client) GetMailbox(name string) (*mailboxes.Mailbox, error) {
 	return mailboxes.GetMailboxByName(c.Client, name)
 }
 
+// GetMailboxWithContext is like GetMailbox, but the request is bound to ctx.
+func (c *Client) GetMailboxWithContext(ctx context.Context, name string) (*mailboxes.Mailbox, error) {
+	return mailboxes.GetMailboxByNameWithContext(ctx, c.Client, name)
+}
+
 // NewAddress is a convenience function for creating a new *emails.Address
 func NewAddress(name, email string) *emails.Address {
 	return &emails.Address{Name: name, Email: email}
 }
 
+// DefaultDrafts and DefaultSent make NewClient find the drafts and sent mailboxes by role.
 const (
-	DefaultDrafts = "Drafts"
-	DefaultSent   = "Sent"
+	DefaultDrafts = ""
+	DefaultSent   = ""
 )
 
 type Addresses []*emails.Address
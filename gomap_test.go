@@ -0,0 +1,91 @@
+package gomap_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cwinters8/gomap"
+	"github.com/cwinters8/gomap/internal/jmaptest"
+	"github.com/cwinters8/gomap/objects/mailboxes"
+	"github.com/cwinters8/gomap/utils"
+)
+
+// fakeServer serves a session and the mailboxes of a German account.
+func fakeServer(t *testing.T, withSent bool) *jmaptest.Server {
+	return jmaptest.NewServer(t, func(method string, args map[string]any) (string, map[string]any) {
+		list := []any{
+			map[string]any{"id": "MB1", "name": "Posteingang", "role": "inbox"},
+			map[string]any{"id": "MB2", "name": "Entwürfe", "role": "drafts"},
+			map[string]any{"id": "MB4", "name": "Papierkorb", "role": "trash"},
+			map[string]any{"id": "MB5", "name": "Ausgang"},
+		}
+		if withSent {
+			list = append(list, map[string]any{"id": "MB3", "name": "Gesendet", "role": "sent"})
+		}
+		return "Mailbox/get", map[string]any{"accountId": "u1", "state": "1", "list": list, "notFound": []any{}}
+	})
+}
+
+func TestNewClientRoles(t *testing.T) {
+	ctx := context.Background()
+	srv := fakeServer(t, true)
+	c, err := gomap.NewClientWithContext(ctx, srv.URL, "token", gomap.DefaultDrafts, gomap.DefaultSent)
+	if err != nil {
+		t.Fatalf("failed to create client: %s", err.Error())
+	}
+	overridden, err := gomap.NewClientWithContext(ctx, srv.URL, "token", "", "Ausgang")
+	if err != nil {
+		t.Fatalf("failed to create client: %s", err.Error())
+	}
+	_, err = gomap.NewClientWithContext(ctx, fakeServer(t, false).URL, "token", gomap.DefaultDrafts, gomap.DefaultSent)
+	var roleErr *mailboxes.RoleNotFoundError
+	cases := utils.Cases{
+		utils.NewCase(c.Drafts.ID != "MB2" || c.Sent.ID != "MB3", "wanted drafts MB2 and sent MB3; got %s and %s", c.Drafts.ID, c.Sent.ID),
+		utils.NewCase(c.Inbox == nil || c.Inbox.ID != "MB1", "wanted inbox MB1; got %v", c.Inbox),
+		utils.NewCase(c.Trash == nil || c.Trash.ID != "MB4", "wanted trash MB4; got %v", c.Trash),
+		utils.NewCase(c.Junk != nil || c.Archive != nil, "wanted no junk or archive mailboxes; got %v and %v", c.Junk, c.Archive),
+		utils.NewCase(overridden.Sent.ID != "MB5", "wanted the sent mailbox named Ausgang; got %s", overridden.Sent.ID),
+		utils.NewCase(!errors.As(err, &roleErr) || roleErr.Role != mailboxes.RoleSent, "wanted a missing sent role error; got %v", err),
+	}
+	cases.Iterator(func(c *utils.Case) {
+		t.Error(c.Message)
+	})
+}
+
+func TestGetEmailsPollsWhilePushIsQuiet(t *testing.T) {
+	var queries atomic.Int32
+	srv := jmaptest.NewServer(t, func(method string, args map[string]any) (string, map[string]any) {
+		switch method {
+		case "Email/query":
+			// the email only matches from the second query, and no change is ever pushed
+			ids := []any{}
+			if queries.Add(1) > 1 {
+				ids = append(ids, "M1")
+			}
+			return method, map[string]any{"accountId": "u1", "queryState": "1", "ids": ids}
+		case "Email/get":
+			list := []any{map[string]any{"id": "M1", "subject": "hello"}}
+			return method, map[string]any{"accountId": "u1", "state": "1", "list": list, "notFound": []any{}}
+		}
+		list := []any{
+			map[string]any{"id": "MB2", "name": "Drafts", "role": "drafts"},
+			map[string]any{"id": "MB3", "name": "Sent", "role": "sent"},
+		}
+		return "Mailbox/get", map[string]any{"accountId": "u1", "state": "1", "list": list, "notFound": []any{}}
+	})
+	c, err := gomap.NewClientWithContext(context.Background(), srv.URL, "token", gomap.DefaultDrafts, gomap.DefaultSent)
+	if err != nil {
+		t.Fatalf("failed to create client: %s", err.Error())
+	}
+	c.PollInterval = 10 * time.Millisecond
+	found, err := c.GetEmails(&gomap.Filter{Text: "hello"}, 1, 5*time.Second)
+	if err != nil {
+		t.Fatalf("failed to get emails: %s", err.Error())
+	}
+	if len(found) != 1 || found[0].ID != "M1" {
+		t.Errorf("wanted email M1; got %v", found)
+	}
+}
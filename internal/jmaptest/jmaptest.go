@@ -0,0 +1,141 @@
+// Package jmaptest provides a fake JMAP server for tests.
+package jmaptest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cwinters8/gomap/client"
+)
+
+// AccountID is the primary mail account of the session the server describes.
+const AccountID = "u1"
+
+// Handler answers a single method call with the name and arguments of its response.
+type Handler func(method string, args map[string]any) (string, map[string]any)
+
+// Server is a JMAP server that answers every method call with a Handler and keeps every request it receives.
+// The ids of created objects are added to the createdIds of requests that carry them.
+//
+// GET requests to its root are answered with the session, and to /events with an event source
+// that streams whatever is passed to Push. Any other request is taken as an API request.
+type Server struct {
+	*httptest.Server
+	// Implicit holds, by method, handlers for a second response the server adds after
+	// that of the call, as it adds Email/set for onSuccessUpdateEmail on EmailSubmission/set.
+	Implicit map[string]Handler
+
+	mu       sync.Mutex
+	requests []map[string]any
+	events   chan string
+}
+
+// NewServer starts a Server that answers method calls with handle. It's closed when the test ends.
+func NewServer(t testing.TB, handle Handler) *Server {
+	s := Server{events: make(chan string, 16)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			if strings.HasPrefix(r.URL.Path, "/events") {
+				s.stream(w, r)
+				return
+			}
+			json.NewEncoder(w).Encode(s.session())
+			return
+		}
+		var req map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("fake api failed to decode request: %s", err.Error())
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		s.requests = append(s.requests, req)
+		s.mu.Unlock()
+		responses := []any{}
+		sent, track := req["createdIds"].(map[string]any)
+		created := map[string]any{}
+		for k, v := range sent {
+			created[k] = v
+		}
+		calls, _ := req["methodCalls"].([]any)
+		for _, raw := range calls {
+			call := raw.([]any)
+			method, args := handle(call[0].(string), call[1].(map[string]any))
+			responses = append(responses, []any{method, args, call[2]})
+			if implicit, ok := s.Implicit[call[0].(string)]; ok {
+				method, args := implicit(call[0].(string), call[1].(map[string]any))
+				responses = append(responses, []any{method, args, call[2]})
+			}
+			if objects, ok := args["created"].(map[string]any); ok && track {
+				for k, v := range objects {
+					created[k] = v.(map[string]any)["id"]
+				}
+			}
+		}
+		resp := map[string]any{
+			"methodResponses": responses,
+			"sessionState":    "s1",
+		}
+		if track {
+			resp["createdIds"] = created
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(s.Close)
+	return &s
+}
+
+func (s *Server) session() *client.Session {
+	return &client.Session{
+		APIURL:          s.URL + "/api",
+		EventSourceURL:  s.URL + "/events?types={types}&closeafter={closeafter}&ping={ping}",
+		PrimaryAccounts: &client.Accounts{Mail: AccountID},
+	}
+}
+
+// NewClient returns a client for the server, with the session it serves.
+func (s *Server) NewClient() *client.Client {
+	return &client.Client{
+		Session:    s.session(),
+		HttpClient: s.Client(),
+	}
+}
+
+// Requests returns the decoded body of every API request received so far.
+func (s *Server) Requests() []map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]map[string]any{}, s.requests...)
+}
+
+// Reset forgets the requests received so far.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+}
+
+// Push sends a StateChange reporting that dataType in the server's account changed to state
+// on the event source.
+func (s *Server) Push(dataType, state string) {
+	s.events <- fmt.Sprintf(`{"@type":"StateChange","changed":{%q:{%q:%q}}}`, AccountID, dataType, state)
+}
+
+func (s *Server) stream(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.(http.Flusher).Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case data := <-s.events:
+			fmt.Fprintf(w, "event: state\ndata: %s\n\n", data)
+			w.(http.Flusher).Flush()
+		}
+	}
+}
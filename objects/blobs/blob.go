@@ -0,0 +1,157 @@
+package blobs
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cwinters8/gomap/client"
+	"github.com/cwinters8/gomap/requests"
+)
+
+// Blob is binary data as returned by Blob/get (RFC 9404 section 4.2).
+// Only the properties that were asked for are populated.
+type Blob struct {
+	ID                string
+	Text              *string           // data:asText; nil when the data isn't valid UTF-8 or wasn't asked for
+	Base64            *string           // data:asBase64
+	IsEncodingProblem bool              // the data couldn't be returned as text
+	IsTruncated       bool              // the data is shorter than the requested length
+	Size              int               // size of the whole blob
+	Digests           map[string]string // base64 digests keyed by algorithm, e.g. "sha-256"
+}
+
+// properties of Blob/get
+const (
+	PropertyData       = "data"
+	PropertyDataText   = "data:asText"
+	PropertyDataBase64 = "data:asBase64"
+	PropertySize       = "size"
+	PropertyDigestSHA  = "digest:sha"
+	PropertyDigest256  = "digest:sha-256"
+)
+
+// Data returns the blob's data, whichever way it was returned.
+func (b *Blob) Data() ([]byte, error) {
+	switch {
+	case b.Base64 != nil:
+		data, err := base64.StdEncoding.DecodeString(*b.Base64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode blob %s: %w", b.ID, err)
+		}
+		return data, nil
+	case b.Text != nil:
+		return []byte(*b.Text), nil
+	}
+	return nil, fmt.Errorf("blob %s has no data", b.ID)
+}
+
+func (b *Blob) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("failed to unmarshal blob to map: %w", err)
+	}
+	blob := Blob{}
+	for k, v := range raw {
+		var err error
+		switch k {
+		case "id":
+			err = json.Unmarshal(v, &blob.ID)
+		case PropertyDataText:
+			err = json.Unmarshal(v, &blob.Text)
+		case PropertyDataBase64:
+			err = json.Unmarshal(v, &blob.Base64)
+		case "isEncodingProblem":
+			err = json.Unmarshal(v, &blob.IsEncodingProblem)
+		case "isTruncated":
+			err = json.Unmarshal(v, &blob.IsTruncated)
+		case PropertySize:
+			err = json.Unmarshal(v, &blob.Size)
+		default:
+			if alg, ok := strings.CutPrefix(k, "digest:"); ok {
+				if blob.Digests == nil {
+					blob.Digests = map[string]string{}
+				}
+				var digest string
+				err = json.Unmarshal(v, &digest)
+				blob.Digests[alg] = digest
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("failed to unmarshal blob property `%s`: %w", k, err)
+		}
+	}
+	*b = blob
+	return nil
+}
+
+// Type provides Blob/get.
+var Type = requests.Type[Blob]{Name: "Blob", Using: []requests.Capability{requests.UsingBlob}}
+
+// Capabilities are the account level limits of urn:ietf:params:jmap:blob (RFC 9404 section 3).
+type Capabilities struct {
+	MaxSizeBlobSet            *int     `json:"maxSizeBlobSet"`
+	MaxDataSources            int      `json:"maxDataSources"`
+	SupportedTypeNames        []string `json:"supportedTypeNames"`
+	SupportedDigestAlgorithms []string `json:"supportedDigestAlgorithms"`
+}
+
+// AccountCapabilities returns the blob capabilities of the account with acctID,
+// or an error when the account doesn't support them.
+func AccountCapabilities(sess *client.Session, acctID string) (*Capabilities, error) {
+	acct, ok := sess.Accounts[acctID]
+	if !ok || acct.AccountCapabilities == nil {
+		return nil, fmt.Errorf("account `%s` not found in session", acctID)
+	}
+	raw, ok := acct.AccountCapabilities.Other[string(requests.UsingBlob)]
+	if !ok {
+		return nil, fmt.Errorf("account `%s` does not support %s", acctID, requests.UsingBlob)
+	}
+	var caps Capabilities
+	if err := json.Unmarshal(raw, &caps); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal blob capabilities: %w", err)
+	}
+	return &caps, nil
+}
+
+// GetArgs are the arguments of Blob/get.
+type GetArgs struct {
+	IDs        []string
+	Properties []string // data and size when nil
+	Offset     int      // where to start reading the data
+	Length     int      // how much of the data to return; the rest of it when zero
+}
+
+// GetCall constructs a Blob/get call.
+func GetCall(acctID string, args *GetArgs) (*requests.Call, error) {
+	if args == nil || len(args.IDs) < 1 {
+		return nil, fmt.Errorf("args.IDs must be populated")
+	}
+	extra := map[string]any{}
+	if args.Offset > 0 {
+		extra["offset"] = args.Offset
+	}
+	if args.Length > 0 {
+		extra["length"] = args.Length
+	}
+	return Type.GetCall(acctID, &requests.GetArgs{
+		IDs:        args.IDs,
+		Properties: args.Properties,
+		Extra:      extra,
+	})
+}
+
+// Get retrieves the blobs in args from the account with acctID.
+func Get(ctx context.Context, c *client.Client, acctID string, args *GetArgs) (found []*Blob, notFound []string, err error) {
+	call, err := GetCall(acctID, args)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to construct Blob/get call: %w", err)
+	}
+	resp, err := requests.SendCall[requests.GetResponse[Blob]](ctx, c, call, requests.Using(Type.Using...))
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp.List, resp.NotFound, nil
+}
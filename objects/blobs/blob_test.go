@@ -0,0 +1,106 @@
+package blobs_test
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/cwinters8/gomap/client"
+	"github.com/cwinters8/gomap/internal/jmaptest"
+	"github.com/cwinters8/gomap/objects/blobs"
+	"github.com/cwinters8/gomap/requests"
+	"github.com/cwinters8/gomap/utils"
+)
+
+func TestBlobs(t *testing.T) {
+	args := map[string]map[string]any{}
+	f := jmaptest.NewServer(t, func(method string, a map[string]any) (string, map[string]any) {
+		args[method] = a
+		var body string
+		switch method {
+		case "Blob/upload":
+			body = `{"accountId":"u1","created":{"b1":{"id":"B1","type":"text/plain","size":5}},"notCreated":{"b2":{"type":"tooLarge"}}}`
+		case "Blob/get":
+			body = `{"accountId":"u1","list":[{"id":"B1","data:asText":"ell","isTruncated":false,"size":5,"digest:sha-256":"abc="},{"id":"B2","data:asBase64":"AAE=","isEncodingProblem":true,"size":2}],"notFound":["B3"]}`
+		case "Blob/lookup":
+			body = `{"accountId":"u1","list":[{"id":"B1","matchedIds":{"Email":["M1"],"Mailbox":["MB1"]}}],"notFound":["B3"]}`
+		case "Blob/copy":
+			body = `{"fromAccountId":"u1","accountId":"u2","copied":{"B1":"B9"},"notCopied":{"B3":{"type":"notFound"}}}`
+		}
+		var resp map[string]any
+		json.Unmarshal([]byte(body), &resp)
+		return method, resp
+	})
+	c := f.NewClient()
+	c.Session.Accounts = map[string]*client.Account{
+		"u1": {AccountCapabilities: &client.AccountCapabilities{Other: map[string]json.RawMessage{
+			string(requests.UsingBlob): json.RawMessage(`{"maxSizeBlobSet":1024,"maxDataSources":16,"supportedTypeNames":["Email","Mailbox"],"supportedDigestAlgorithms":["sha-256"]}`),
+		}}},
+	}
+	ctx := context.Background()
+
+	uploaded, err := blobs.UploadData(ctx, c, "u1", map[string]*blobs.Upload{
+		"b1": {Data: []*blobs.DataSource{blobs.Text("he"), blobs.Bytes([]byte("llo"))}, Type: "text/plain"},
+		"b2": {Data: []*blobs.DataSource{blobs.Slice("B0", 10, -1)}},
+	})
+	if err != nil {
+		t.Fatalf("failed to upload blobs: %s", err.Error())
+	}
+	found, notFound, err := blobs.Get(ctx, c, "u1", &blobs.GetArgs{
+		IDs:        []string{"B1", "B2", "B3"},
+		Properties: []string{blobs.PropertyDataText, blobs.PropertySize, blobs.PropertyDigest256},
+		Offset:     1,
+		Length:     3,
+	})
+	if err != nil {
+		t.Fatalf("failed to get blobs: %s", err.Error())
+	}
+	lookup, err := blobs.Lookup(ctx, c, "u1", []string{"Email", "Mailbox"}, []string{"B1", "B3"})
+	if err != nil {
+		t.Fatalf("failed to look up blobs: %s", err.Error())
+	}
+	copied, err := blobs.Copy(ctx, c, "u1", "u2", []string{"B1", "B3"})
+	if err != nil {
+		t.Fatalf("failed to copy blobs: %s", err.Error())
+	}
+	caps, err := blobs.AccountCapabilities(c.Session, "u1")
+	if err != nil {
+		t.Fatalf("failed to get blob capabilities: %s", err.Error())
+	}
+	if len(found) != 2 {
+		t.Fatalf("wanted 2 blobs; got %d", len(found))
+	}
+	text, _ := found[0].Data()
+	bin, _ := found[1].Data()
+	using := map[string][]string{}
+	for _, req := range f.Requests() {
+		method := req["methodCalls"].([]any)[0].([]any)[0].(string)
+		for _, u := range req["using"].([]any) {
+			using[method] = append(using[method], u.(string))
+		}
+	}
+	sources := args["Blob/upload"]["create"].(map[string]any)["b2"].(map[string]any)["data"].([]any)[0].(map[string]any)
+
+	cases := utils.Cases{
+		utils.NewCase(!slices.Contains(using["Blob/upload"], string(requests.UsingBlob)), "wanted Blob/upload to use the blob capability; got %v", using["Blob/upload"]),
+		utils.NewCase(!slices.Contains(using["Blob/get"], string(requests.UsingBlob)), "wanted Blob/get to use the blob capability; got %v", using["Blob/get"]),
+		utils.NewCase(!slices.Contains(using["Blob/lookup"], string(requests.UsingBlob)), "wanted Blob/lookup to use the blob capability; got %v", using["Blob/lookup"]),
+		utils.NewCase(slices.Contains(using["Blob/copy"], string(requests.UsingBlob)), "wanted Blob/copy to use only core; got %v", using["Blob/copy"]),
+		utils.NewCase(uploaded.Created["b1"] == nil || uploaded.Created["b1"].ID != "B1", "wanted b1 created as B1; got %v", uploaded.Created),
+		utils.NewCase(uploaded.NotCreated["b2"] == nil || uploaded.NotCreated["b2"].Type != requests.SetErrorTooLarge, "wanted b2 not created as too large; got %v", uploaded.NotCreated),
+		utils.NewCase(sources["blobId"] != "B0" || sources["offset"] != float64(10) || sources["length"] != nil, "wanted a slice of B0 from 10 to the end; got %v", sources),
+		utils.NewCase(args["Blob/get"]["offset"] != float64(1) || args["Blob/get"]["length"] != float64(3), "wanted offset 1 and length 3; got %v", args["Blob/get"]),
+		utils.NewCase(string(text) != "ell", "wanted text `ell`; got `%s`", text),
+		utils.NewCase(found[0].Size != 5 || found[0].Digests["sha-256"] != "abc=", "wanted size 5 and a sha-256 digest; got %+v", found[0]),
+		utils.NewCase(!found[1].IsEncodingProblem || len(bin) != 2 || bin[1] != 1, "wanted 2 bytes with an encoding problem; got %v", bin),
+		utils.NewCase(len(notFound) != 1, "wanted 1 blob not found; got %v", notFound),
+		utils.NewCase(len(lookup.List) != 1 || lookup.List[0].MatchedIDs["Email"][0] != "M1", "wanted B1 matched by M1; got %v", lookup.List),
+		utils.NewCase(args["Blob/copy"]["fromAccountId"] != "u1" || args["Blob/copy"]["accountId"] != "u2", "wanted a copy from u1 to u2; got %v", args["Blob/copy"]),
+		utils.NewCase(copied.Copied["B1"] != "B9" || copied.NotCopied["B3"] == nil, "wanted B1 copied as B9 and B3 not copied; got %+v", copied),
+		utils.NewCase(caps.MaxDataSources != 16 || !slices.Contains(caps.SupportedTypeNames, "Email"), "wanted blob capabilities; got %+v", caps),
+	}
+	cases.Iterator(func(c *utils.Case) {
+		t.Error(c.Message)
+	})
+}
@@ -0,0 +1,44 @@
+package blobs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cwinters8/gomap/client"
+	"github.com/cwinters8/gomap/requests"
+)
+
+// CopyResponse is the response to Blob/copy (RFC 8620 section 6.3).
+type CopyResponse struct {
+	FromAccountID string                        `json:"fromAccountId"`
+	AccountID     string                        `json:"accountId"`
+	Copied        map[string]string             `json:"copied"` // original blob id to the id in the destination account
+	NotCopied     map[string]*requests.SetError `json:"notCopied"`
+}
+
+// CopyCall constructs a Blob/copy call copying the blobs with ids from the account
+// with fromAcctID to the account with toAcctID.
+func CopyCall(fromAcctID, toAcctID string, ids []string) (*requests.Call, error) {
+	if len(ids) < 1 {
+		return nil, fmt.Errorf("no blob ids provided")
+	}
+	return &requests.Call{
+		ID:        requests.NewCallID(),
+		AccountID: toAcctID,
+		Method:    "Blob/copy",
+		Arguments: map[string]any{
+			"fromAccountId": fromAcctID,
+			"blobIds":       ids,
+		},
+	}, nil
+}
+
+// Copy copies the blobs with ids from the account with fromAcctID to the account with toAcctID.
+// Blob/copy is part of the core capability, so it works on servers without RFC 9404.
+func Copy(ctx context.Context, c *client.Client, fromAcctID, toAcctID string, ids []string) (*CopyResponse, error) {
+	call, err := CopyCall(fromAcctID, toAcctID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct Blob/copy call: %w", err)
+	}
+	return requests.SendCall[CopyResponse](ctx, c, call)
+}
@@ -0,0 +1,51 @@
+package blobs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cwinters8/gomap/client"
+	"github.com/cwinters8/gomap/requests"
+)
+
+// Match lists the objects referring to a blob, by data type name (RFC 9404 section 4.3).
+type Match struct {
+	ID         string              `json:"id"`
+	MatchedIDs map[string][]string `json:"matchedIds"` // e.g. "Email" to the ids of the emails using the blob
+}
+
+// LookupResponse is the response to Blob/lookup.
+type LookupResponse struct {
+	AccountID string   `json:"accountId"`
+	List      []*Match `json:"list"`
+	NotFound  []string `json:"notFound"`
+}
+
+// LookupCall constructs a Blob/lookup call finding the objects of typeNames, such as
+// "Email" or "Mailbox", that refer to the blobs with ids.
+func LookupCall(acctID string, typeNames []string, ids []string) (*requests.Call, error) {
+	if len(typeNames) < 1 {
+		return nil, fmt.Errorf("no type names provided")
+	}
+	if len(ids) < 1 {
+		return nil, fmt.Errorf("no blob ids provided")
+	}
+	return &requests.Call{
+		ID:        requests.NewCallID(),
+		AccountID: acctID,
+		Method:    "Blob/lookup",
+		Arguments: map[string]any{
+			"typeNames": typeNames,
+			"ids":       ids,
+		},
+	}, nil
+}
+
+// Lookup finds the objects of typeNames in the account with acctID that refer to the blobs with ids.
+func Lookup(ctx context.Context, c *client.Client, acctID string, typeNames []string, ids []string) (*LookupResponse, error) {
+	call, err := LookupCall(acctID, typeNames, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct Blob/lookup call: %w", err)
+	}
+	return requests.SendCall[LookupResponse](ctx, c, call, requests.Using(requests.UsingBlob))
+}
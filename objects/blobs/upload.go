@@ -0,0 +1,90 @@
+package blobs
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/cwinters8/gomap/client"
+	"github.com/cwinters8/gomap/requests"
+)
+
+// DataSource is one piece of the data of a Blob/upload (RFC 9404 section 4.1).
+// Use Text, Bytes or Slice to create one.
+type DataSource struct {
+	Text   *string `json:"data:asText,omitempty"`
+	Base64 *string `json:"data:asBase64,omitempty"`
+	BlobID string  `json:"blobId,omitempty"`
+	Offset *int    `json:"offset,omitempty"`
+	Length *int    `json:"length,omitempty"`
+}
+
+// Text is a data source holding s.
+func Text(s string) *DataSource {
+	return &DataSource{Text: &s}
+}
+
+// Bytes is a data source holding b.
+func Bytes(b []byte) *DataSource {
+	encoded := base64.StdEncoding.EncodeToString(b)
+	return &DataSource{Base64: &encoded}
+}
+
+// Slice is a data source holding length bytes of the existing blob with blobID, starting at offset.
+// A negative length takes the rest of the blob.
+func Slice(blobID string, offset, length int) *DataSource {
+	d := DataSource{BlobID: blobID}
+	if offset > 0 {
+		d.Offset = &offset
+	}
+	if length >= 0 {
+		d.Length = &length
+	}
+	return &d
+}
+
+// Upload is a blob to create from its data sources, which are concatenated.
+type Upload struct {
+	Data []*DataSource `json:"data"`
+	Type string        `json:"type,omitempty"`
+}
+
+// Created describes a blob created by Blob/upload.
+type Created struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Size int    `json:"size"`
+}
+
+// UploadResponse is the response to Blob/upload.
+type UploadResponse struct {
+	AccountID  string                        `json:"accountId"`
+	Created    map[string]*Created           `json:"created"`
+	NotCreated map[string]*requests.SetError `json:"notCreated"`
+}
+
+// UploadCall constructs a Blob/upload call creating a blob for each entry of uploads,
+// keyed by creation id.
+//
+// Later calls in the same request can refer to a created blob as "#" followed by its
+// creation id, so small attachments can be uploaded along with the Email/set that uses them.
+func UploadCall(acctID string, uploads map[string]*Upload) (*requests.Call, error) {
+	if len(uploads) < 1 {
+		return nil, fmt.Errorf("no uploads provided")
+	}
+	return &requests.Call{
+		ID:        requests.NewCallID(),
+		AccountID: acctID,
+		Method:    "Blob/upload",
+		Arguments: map[string]any{"create": uploads},
+	}, nil
+}
+
+// UploadData creates blobs from uploads in the account with acctID within a normal API request.
+func UploadData(ctx context.Context, c *client.Client, acctID string, uploads map[string]*Upload) (*UploadResponse, error) {
+	call, err := UploadCall(acctID, uploads)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct Blob/upload call: %w", err)
+	}
+	return requests.SendCall[UploadResponse](ctx, c, call, requests.Using(requests.UsingBlob))
+}
@@ -4,9 +4,14 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/cwinters8/gomap/requests"
+
 	"github.com/google/uuid"
 )
 
+// Type provides the standard JMAP methods for emails.
+var Type = requests.Type[Email]{Name: "Email"}
+
 type Email struct {
 	ID         string     `json:"id"`
 	RequestID  uuid.UUID  `json:"-"`
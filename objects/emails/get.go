@@ -1,6 +1,7 @@
 package emails
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strconv"
@@ -8,17 +9,19 @@ import (
 
 	"github.com/cwinters8/gomap/client"
 	"github.com/cwinters8/gomap/requests"
-	"github.com/cwinters8/gomap/utils"
-
-	"github.com/google/uuid"
 )
 
 func GetEmails(c *client.Client, emailIDs []string) (found []*Email, notFound []string, err error) {
-	call, err := GetCall(c.Session.PrimaryAccounts.Mail, emailIDs)
+	return GetEmailsWithContext(context.Background(), c, emailIDs)
+}
+
+// GetEmailsWithContext is like GetEmails, but the request is bound to ctx.
+func GetEmailsWithContext(ctx context.Context, c *client.Client, emailIDs []string) (found []*Email, notFound []string, err error) {
+	call, err := GetCall(c.MailAccountID(), emailIDs)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to construct Get call: %w", err)
 	}
-	responses, err := requests.Request(c, []*requests.Call{call}, false)
+	responses, err := requests.RequestWithContext(ctx, c, []*requests.Call{call}, false)
 	if err != nil {
 		return nil, nil, fmt.Errorf("request failure: %w", err)
 	}
@@ -26,55 +29,61 @@ func GetEmails(c *client.Client, emailIDs []string) (found []*Email, notFound []
 }
 
 func GetCall(acctID string, emailIDs []string) (*requests.Call, error) {
-	callID, err := uuid.NewRandom()
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate new uuid: %w", err)
-	}
-	return &requests.Call{
-		ID:        callID,
-		AccountID: acctID,
-		Method:    "Email/get",
-		Arguments: map[string]any{
-			"ids": emailIDs,
-			"properties": []string{
-				"mailboxIds",
-				"from",
-				"to",
-				"subject",
-				"bodyValues",
-				"bodyStructure",
-			},
+	return Type.GetCall(acctID, &requests.GetArgs{
+		IDs: emailIDs,
+		Properties: []string{
+			"mailboxIds",
+			"from",
+			"to",
+			"subject",
+			"bodyValues",
+			"bodyStructure",
+		},
+		Extra: map[string]any{
 			"bodyProperties":      []string{"type"},
 			"fetchTextBodyValues": true,
 			"fetchHTMLBodyValues": true,
 		},
-	}, nil
+	})
 }
 
-func ParseRawResponseBody(body map[string]any) (found []*Email, notFound []string, err error) {
-	b, err := json.Marshal(body)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to marshal response body to json: %w", err)
-	}
-	var respBody responseBody
-	if err := json.Unmarshal(b, &respBody); err != nil {
+func ParseRawResponseBody(body json.RawMessage) (found []*Email, notFound []string, err error) {
+	var resp requests.GetResponse[Email]
+	if err := json.Unmarshal(body, &resp); err != nil {
 		return nil, nil, fmt.Errorf("failed to unmarshal response body: %w", err)
 	}
-	emails := []*Email{}
-	for _, rawEmail := range respBody.List {
-		emails = append(emails, &Email{
-			ID:         rawEmail.ID,
-			MailboxIDs: rawEmail.MailboxIDs.IDs,
-			From:       rawEmail.From,
-			To:         rawEmail.To,
-			Subject:    rawEmail.Subject,
-			Body: &Body{
-				Value: rawEmail.BodyValue.Value,
-				Type:  BodyType(rawEmail.BodyStructure.Type),
-			},
-		})
-	}
-	return emails, respBody.NotFound, nil
+	if resp.List == nil {
+		resp.List = []*Email{}
+	}
+	return resp.List, resp.NotFound, nil
+}
+
+// UnmarshalJSON decodes an email as returned by Email/get and Email/set.
+func (e *Email) UnmarshalJSON(b []byte) error {
+	var raw result
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	*e = Email{
+		ID:       raw.ID,
+		Keywords: raw.Keywords,
+		From:     raw.From,
+		To:       raw.To,
+		Subject:  raw.Subject,
+	}
+	if raw.MailboxIDs != nil {
+		e.MailboxIDs = raw.MailboxIDs.IDs
+	}
+	if raw.BodyValue != nil || raw.BodyStructure != nil {
+		e.Body = &Body{}
+		if raw.BodyValue != nil {
+			e.Body.Value = raw.BodyValue.Value
+		}
+		if raw.BodyStructure != nil {
+			e.Body.Type = BodyType(raw.BodyStructure.Type)
+		}
+	}
+	return nil
 }
 
 func (e *Email) Get(acctID string) (*requests.Call, error) {
@@ -85,8 +94,8 @@ func (e *Email) Get(acctID string) (*requests.Call, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to construct Get call: %w", err)
 	}
-	call.OnSuccess = func(m map[string]any) error {
-		found, _, err := ParseRawResponseBody(m)
+	call.OnSuccess = func(body json.RawMessage) error {
+		found, _, err := ParseRawResponseBody(body)
 		if err != nil {
 			return fmt.Errorf("failed to parse raw response body: %w", err)
 		}
@@ -104,14 +113,10 @@ func (e *Email) Get(acctID string) (*requests.Call, error) {
 	return call, nil
 }
 
-type responseBody struct {
-	List     []*result `json:"list"`
-	NotFound []string  `json:"notFound"`
-}
-
 type result struct {
 	ID            string         `json:"id"`
 	MailboxIDs    *mailboxes     `json:"mailboxIds"`
+	Keywords      *Keywords      `json:"keywords"`
 	From          []*Address     `json:"from"`
 	To            []*Address     `json:"to"`
 	Subject       string         `json:"subject"`
@@ -143,7 +148,9 @@ type bodyValue struct {
 }
 
 func (v *bodyValue) UnmarshalJSON(b []byte) error {
-	var raw map[string]map[string]any
+	var raw map[string]struct {
+		Value string `json:"value"`
+	}
 	if err := json.Unmarshal(b, &raw); err != nil {
 		return fmt.Errorf("failed to unmarshal body values to map: %w", err)
 	}
@@ -153,11 +160,7 @@ func (v *bodyValue) UnmarshalJSON(b []byte) error {
 		if err != nil {
 			return fmt.Errorf("failed to convert key `%s` to int: %w", k, err)
 		}
-		val, ok := v["value"].(string)
-		if !ok {
-			return fmt.Errorf("failed to cast value to string. %s", utils.Describe(v["value"]))
-		}
-		values[key-1] = val
+		values[key-1] = v.Value
 	}
 	v.Value = strings.Join(values, " ")
 	return nil
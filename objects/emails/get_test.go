@@ -22,7 +22,7 @@ func TestEmailGet(t *testing.T) {
 		e := emails.Email{
 			ID: "M1cb24edb211ae50b4ed508ad",
 		}
-		call, err := e.Get(c.Session.PrimaryAccounts.Mail)
+		call, err := e.Get(c.MailAccountID())
 		if err != nil {
 			t.Fatalf("failed to construct new call for Email/get: %s", err.Error())
 		}
@@ -1,14 +1,13 @@
 package emails
 
 import (
+	"context"
 	"fmt"
 	"time"
 
 	"github.com/cwinters8/gomap/client"
 	"github.com/cwinters8/gomap/parse"
 	"github.com/cwinters8/gomap/requests"
-
-	"github.com/google/uuid"
 )
 
 type Filter struct {
@@ -19,23 +18,16 @@ type Filter struct {
 }
 
 func Query(c *client.Client, filter *Filter) (emailIDs []string, err error) {
-	id, err := uuid.NewRandom()
+	return QueryWithContext(context.Background(), c, filter)
+}
+
+// QueryWithContext is like Query, but the request is bound to ctx.
+func QueryWithContext(ctx context.Context, c *client.Client, filter *Filter) (emailIDs []string, err error) {
+	call, err := QueryCall(c.MailAccountID(), filter)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate new uuid: %w", err)
-	}
-	call := requests.Call{
-		ID:        id,
-		AccountID: c.Session.PrimaryAccounts.Mail,
-		Method:    "Email/query",
-		Arguments: map[string]any{
-			"filter": filter,
-			"sort": []map[string]any{{
-				"isAscending": false,
-				"property":    "receivedAt",
-			}},
-		},
-	}
-	responses, err := requests.Request(c, []*requests.Call{&call}, false)
+		return nil, fmt.Errorf("failed to construct query call: %w", err)
+	}
+	responses, err := requests.RequestWithContext(ctx, c, []*requests.Call{call}, false)
 	if err != nil {
 		return nil, fmt.Errorf("query request failure: %w", err)
 	}
@@ -44,3 +36,76 @@ func Query(c *client.Client, filter *Filter) (emailIDs []string, err error) {
 	}
 	return parse.QueryResponseBody(responses[0].Body)
 }
+
+// QueryCall constructs an Email/query call for emails matching filter, newest first.
+func QueryCall(acctID string, filter *Filter) (*requests.Call, error) {
+	return Type.QueryCall(acctID, queryArgs(filter))
+}
+
+func queryArgs(filter *Filter) *requests.QueryArgs {
+	return &requests.QueryArgs{
+		Filter: filter,
+		Sort: []*requests.Comparator{{
+			Property:    "receivedAt",
+			IsAscending: false,
+		}},
+	}
+}
+
+// QueryChangesCall constructs an Email/queryChanges call for the query of QueryCall,
+// returning the changes to its results since sinceQueryState.
+// upToID may be empty or the last id held when only the top of the results is held.
+func QueryChangesCall(acctID string, filter *Filter, sinceQueryState string, upToID string, maxChanges int) (*requests.Call, error) {
+	args := queryArgs(filter)
+	return Type.QueryChangesCall(acctID, &requests.QueryChangesArgs{
+		SinceQueryState: sinceQueryState,
+		Filter:          args.Filter,
+		Sort:            args.Sort,
+		UpToID:          upToID,
+		MaxChanges:      maxChanges,
+	})
+}
+
+// Refresh keeps results, the ids of the emails matching filter newest first, up to date
+// through Email/queryChanges, running the query again only when the server can't calculate the changes.
+// Start with an empty QueryResults, optionally with a Limit, to run the query the first time.
+//
+//	results := &requests.QueryResults{Limit: 50}
+//	_, err := emails.Refresh(ctx, c, filter, results) // runs the query
+//	...
+//	changes, err := emails.Refresh(ctx, c, filter, results) // applies what changed since
+func Refresh(ctx context.Context, c *client.Client, filter *Filter, results *requests.QueryResults) (*requests.QueryChangesResponse, error) {
+	return Type.Refresh(ctx, c, c.MailAccountID(), queryArgs(filter), results)
+}
+
+// QueryAndGet retrieves the emails matching filter in a single request,
+// passing the ids found by Email/query to Email/get with a result reference.
+func QueryAndGet(c *client.Client, filter *Filter) (found []*Email, err error) {
+	return QueryAndGetWithContext(context.Background(), c, filter)
+}
+
+// QueryAndGetWithContext is like QueryAndGet, but the request is bound to ctx.
+func QueryAndGetWithContext(ctx context.Context, c *client.Client, filter *Filter) (found []*Email, err error) {
+	query, err := QueryCall(c.MailAccountID(), filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct query call: %w", err)
+	}
+	get, err := GetCall(c.MailAccountID(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct Get call: %w", err)
+	}
+	if err := get.SetReference("ids", query.Reference("/ids")); err != nil {
+		return nil, fmt.Errorf("failed to reference query results: %w", err)
+	}
+	responses, err := requests.RequestWithContext(ctx, c, []*requests.Call{query, get}, false)
+	if err != nil {
+		return nil, fmt.Errorf("query request failure: %w", err)
+	}
+	for _, resp := range responses {
+		if resp.ID == get.ID {
+			found, _, err := ParseRawResponseBody(resp.Body)
+			return found, err
+		}
+	}
+	return nil, fmt.Errorf("no Email/get response returned from request")
+}
@@ -48,3 +48,26 @@ func TestFilterJSON(t *testing.T) {
 		t.Error(c.Message)
 	})
 }
+
+func TestQueryChangesCall(t *testing.T) {
+	filter := emails.Filter{InMailboxID: "xyz"}
+	call, err := emails.QueryChangesCall("u1", &filter, "q1", "M9", 0)
+	if err != nil {
+		t.Fatalf("failed to construct query changes call: %s", err.Error())
+	}
+	_, maxChanges := call.Arguments["maxChanges"]
+	cases := utils.Cases{
+		utils.NewCase(call.Method != "Email/queryChanges", "wanted method Email/queryChanges; got %s", call.Method),
+		utils.NewCase(call.Arguments["sinceQueryState"] != "q1", "wanted sinceQueryState q1; got %v", call.Arguments["sinceQueryState"]),
+		utils.NewCase(call.Arguments["upToId"] != "M9", "wanted upToId M9; got %v", call.Arguments["upToId"]),
+		utils.NewCase(call.Arguments["filter"] != &filter, "wanted the query's filter; got %v", call.Arguments["filter"]),
+		utils.NewCase(call.Arguments["sort"] == nil, "wanted the query's sort"),
+		utils.NewCase(maxChanges, "wanted zero maxChanges to be left out"),
+	}
+	cases.Iterator(func(c *utils.Case) {
+		t.Error(c.Message)
+	})
+	if _, err := emails.QueryChangesCall("u1", &filter, "", "", 0); err == nil {
+		t.Error("wanted an error without a query state")
+	}
+}
@@ -24,7 +24,7 @@ func TestEmailSet(t *testing.T) {
 	m := mailboxes.Mailbox{
 		Name: "Drafts",
 	}
-	boxCall, err := m.Query(c.Session.PrimaryAccounts.Mail)
+	boxCall, err := m.Query(c.MailAccountID())
 	if err != nil {
 		t.Fatalf("failed to construct new query call: %s", err.Error())
 	}
@@ -51,7 +51,7 @@ func TestEmailSet(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to construct new email: %s", err.Error())
 	}
-	call, err := e.Set(c.Session.PrimaryAccounts.Mail)
+	call, err := e.Set(c.MailAccountID())
 	if err != nil {
 		t.Fatalf("failed to construct set call: %s", err.Error())
 	}
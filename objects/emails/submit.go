@@ -1,32 +1,48 @@
 package emails
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
 	"github.com/cwinters8/gomap/client"
+	"github.com/cwinters8/gomap/objects/identities"
 	"github.com/cwinters8/gomap/requests"
 
 	"github.com/google/uuid"
 )
 
 func (e *Email) Submit(c *client.Client, draftMailboxID, sentMailboxID string) (submissionID string, err error) {
-	identityID, err := getIdentityID(c, e.From[0].Email)
+	return e.SubmitWithContext(context.Background(), c, draftMailboxID, sentMailboxID)
+}
+
+// SubmitWithContext is like Submit, but the requests are bound to ctx.
+func (e *Email) SubmitWithContext(ctx context.Context, c *client.Client, draftMailboxID, sentMailboxID string) (submissionID string, err error) {
+	identityID, err := getIdentityID(ctx, c, e.From[0].Email)
 	if err != nil {
 		return "", fmt.Errorf("failed to get identity id: %w", err)
 	}
-	call, err := SubmitCall(e.RequestID, identityID, c.Session.PrimaryAccounts.Mail, e.ID, draftMailboxID, sentMailboxID)
+	call, err := SubmitCall(e.RequestID, identityID, c.MailAccountID(), e.ID, draftMailboxID, sentMailboxID)
 	if err != nil {
 		return "", fmt.Errorf("failed to construct Submit call: %w", err)
 	}
-	responses, err := requests.Request(c, []*requests.Call{call}, true)
+	responses, err := requests.RequestWithContext(ctx, c, []*requests.Call{call}, true)
 	if err != nil {
 		return "", fmt.Errorf("submit request failure: %w", err)
 	}
-	if len(responses) < 1 {
-		return "", fmt.Errorf("no responses returned")
+	// the server follows EmailSubmission/set with an implicit Email/set
+	// sharing the same call id when onSuccessUpdateEmail is used
+	var submission *requests.Response
+	for _, resp := range responses {
+		if resp.Method == call.Method {
+			submission = resp
+			break
+		}
+	}
+	if submission == nil {
+		return "", fmt.Errorf("no %s response returned", call.Method)
 	}
-	created, err := ParseSubmitResponseBody(e.RequestID, responses[0].Body)
+	created, err := ParseSubmitResponseBody(e.RequestID, submission.Body)
 	if err != nil {
 		return "", fmt.Errorf("failed to parse response body: %w", err)
 	}
@@ -49,10 +65,6 @@ func (e *Email) Submit(c *client.Client, draftMailboxID, sentMailboxID string) (
 }
 
 func SubmitCall(requestID uuid.UUID, identityID, acctID, emailID, draftMailboxID, sentMailboxID string) (*requests.Call, error) {
-	callID, err := uuid.NewRandom()
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate new uuid: %w", err)
-	}
 	args := map[string]any{
 		"create": map[string]map[string]string{
 			requestID.String(): {
@@ -75,20 +87,16 @@ func SubmitCall(requestID uuid.UUID, identityID, acctID, emailID, draftMailboxID
 		args["onSuccessUpdateEmail"] = map[string]map[string]any{key: onSuccess}
 	}
 	return &requests.Call{
-		ID:        callID,
+		ID:        requests.NewCallID(),
 		AccountID: acctID,
 		Method:    "EmailSubmission/set",
 		Arguments: args,
 	}, nil
 }
 
-func ParseSubmitResponseBody(requestID uuid.UUID, body map[string]any) (createdID string, err error) {
-	b, err := json.Marshal(body)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal body to json: %w", err)
-	}
-	var resp setResponse
-	if err := json.Unmarshal(b, &resp); err != nil {
+func ParseSubmitResponseBody(requestID uuid.UUID, body json.RawMessage) (createdID string, err error) {
+	var resp requests.SetResponse[submission]
+	if err := json.Unmarshal(body, &resp); err != nil {
 		return "", fmt.Errorf("failed to unmarshal submission response: %w", err)
 	}
 	if len(resp.NotCreated) > 0 {
@@ -105,43 +113,18 @@ func ParseSubmitResponseBody(requestID uuid.UUID, body map[string]any) (createdI
 	return "", fmt.Errorf("request id %s not found", requestID.String())
 }
 
-func getIdentityID(c *client.Client, email string) (string, error) {
-	id, err := uuid.NewRandom()
-	if err != nil {
-		return "", fmt.Errorf("failed to generate new uuid: %w", err)
-	}
-	call := requests.Call{
-		ID:        id,
-		Method:    "Identity/get",
-		AccountID: c.Session.PrimaryAccounts.Mail,
-		Arguments: map[string]any{},
-	}
-	responses, err := requests.Request(c, []*requests.Call{&call}, true)
+func getIdentityID(ctx context.Context, c *client.Client, email string) (string, error) {
+	ids, err := identities.GetIdentitiesWithContext(ctx, c)
 	if err != nil {
 		return "", fmt.Errorf("request failure: %w", err)
 	}
-	b, err := json.Marshal(responses[0].Body)
+	i, err := identities.Find(ids, email)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal identity body to json: %w", err)
-	}
-	var resp identityGetResponse
-	if err := json.Unmarshal(b, &resp); err != nil {
-		return "", fmt.Errorf("failed to unmarshal identity response: %w", err)
+		return "", err
 	}
-	for _, i := range resp.List {
-		if i.Email == email {
-			return i.ID, nil
-		}
-	}
-	return "", fmt.Errorf("failed to find email `%s` in identity list", email)
-}
-
-type identityGetResponse struct {
-	List []*identity `json:"list"`
+	return i.ID, nil
 }
 
-type identity struct {
-	ID    string `json:"id"`
-	Name  string `json:"name"`
-	Email string `json:"email"`
+type submission struct {
+	ID string `json:"id"`
 }
@@ -0,0 +1,53 @@
+package identities
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cwinters8/gomap/client"
+	"github.com/cwinters8/gomap/requests"
+)
+
+// Identity is an address the user is allowed to send mail from (RFC 8621 section 6).
+type Identity struct {
+	ID            string     `json:"id"`
+	Name          string     `json:"name"`
+	Email         string     `json:"email"`
+	ReplyTo       []*Address `json:"replyTo"`
+	Bcc           []*Address `json:"bcc"`
+	TextSignature string     `json:"textSignature"`
+	HTMLSignature string     `json:"htmlSignature"`
+	MayDelete     bool       `json:"mayDelete"`
+}
+
+type Address struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// Type provides the standard JMAP methods for identities.
+var Type = requests.Type[Identity]{Name: "Identity", Using: []requests.Capability{requests.UsingSubmission}}
+
+// GetIdentities retrieves every identity of the client's mail account.
+func GetIdentities(c *client.Client) ([]*Identity, error) {
+	return GetIdentitiesWithContext(context.Background(), c)
+}
+
+// GetIdentitiesWithContext is like GetIdentities, but the request is bound to ctx.
+func GetIdentitiesWithContext(ctx context.Context, c *client.Client) ([]*Identity, error) {
+	resp, err := Type.Get(ctx, c, c.MailAccountID(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.List, nil
+}
+
+// Find returns the identity in ids sending from email.
+func Find(ids []*Identity, email string) (*Identity, error) {
+	for _, i := range ids {
+		if i.Email == email {
+			return i, nil
+		}
+	}
+	return nil, fmt.Errorf("failed to find email `%s` in identity list", email)
+}
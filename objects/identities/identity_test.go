@@ -0,0 +1,24 @@
+package identities_test
+
+import (
+	"testing"
+
+	"github.com/cwinters8/gomap/objects/identities"
+)
+
+func TestFind(t *testing.T) {
+	ids := []*identities.Identity{
+		{ID: "I1", Email: "one@example.com"},
+		{ID: "I2", Email: "two@example.com"},
+	}
+	i, err := identities.Find(ids, "two@example.com")
+	if err != nil {
+		t.Fatalf("failed to find identity: %s", err.Error())
+	}
+	if i.ID != "I2" {
+		t.Errorf("wanted identity I2; got %s", i.ID)
+	}
+	if _, err := identities.Find(ids, "three@example.com"); err == nil {
+		t.Error("wanted an error for an unknown email")
+	}
+}
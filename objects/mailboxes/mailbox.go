@@ -1,29 +1,109 @@
 package mailboxes
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 
 	"github.com/cwinters8/gomap/client"
 	"github.com/cwinters8/gomap/parse"
 	"github.com/cwinters8/gomap/requests"
-
-	"github.com/google/uuid"
 )
 
+// Mailbox is a named set of emails (RFC 8621 section 2).
 type Mailbox struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID            string  `json:"id"`
+	Name          string  `json:"name"`
+	ParentID      string  `json:"parentId"` // empty for a top level mailbox
+	Role          Role    `json:"role"`     // empty for a mailbox without a role
+	SortOrder     int     `json:"sortOrder"`
+	TotalEmails   int     `json:"totalEmails"`
+	UnreadEmails  int     `json:"unreadEmails"`
+	TotalThreads  int     `json:"totalThreads"`
+	UnreadThreads int     `json:"unreadThreads"`
+	MyRights      *Rights `json:"myRights"`
+	IsSubscribed  bool    `json:"isSubscribed"`
+}
+
+// Role identifies what a mailbox is used for, independent of its name
+// (the IANA IMAP Mailbox Name Attributes registry).
+type Role string
+
+const (
+	RoleInbox     Role = "inbox"
+	RoleDrafts    Role = "drafts"
+	RoleSent      Role = "sent"
+	RoleTrash     Role = "trash"
+	RoleJunk      Role = "junk"
+	RoleArchive   Role = "archive"
+	RoleAll       Role = "all"
+	RoleFlagged   Role = "flagged"
+	RoleImportant Role = "important"
+)
+
+// Rights are what the user may do with a mailbox and the emails in it.
+type Rights struct {
+	MayReadItems   bool `json:"mayReadItems"`
+	MayAddItems    bool `json:"mayAddItems"`
+	MayRemoveItems bool `json:"mayRemoveItems"`
+	MaySetSeen     bool `json:"maySetSeen"`
+	MaySetKeywords bool `json:"maySetKeywords"`
+	MayCreateChild bool `json:"mayCreateChild"`
+	MayRename      bool `json:"mayRename"`
+	MayDelete      bool `json:"mayDelete"`
+	MaySubmit      bool `json:"maySubmit"`
+}
+
+// Type provides the standard JMAP methods for mailboxes.
+var Type = requests.Type[Mailbox]{Name: "Mailbox"}
+
+// GetMailboxes retrieves the mailboxes with mailboxIDs from the client's mail account,
+// or every mailbox when mailboxIDs is nil.
+func GetMailboxes(c *client.Client, mailboxIDs []string) (found []*Mailbox, notFound []string, err error) {
+	return GetMailboxesWithContext(context.Background(), c, mailboxIDs)
+}
+
+// GetMailboxesWithContext is like GetMailboxes, but the request is bound to ctx.
+func GetMailboxesWithContext(ctx context.Context, c *client.Client, mailboxIDs []string) (found []*Mailbox, notFound []string, err error) {
+	resp, err := Type.Get(ctx, c, c.MailAccountID(), &requests.GetArgs{IDs: mailboxIDs})
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp.List, resp.NotFound, nil
+}
+
+// ChangesResponse is the response to Mailbox/changes.
+type ChangesResponse struct {
+	requests.ChangesResponse
+	// UpdatedProperties lists the only properties that changed on the updated mailboxes,
+	// such as just the counts after new mail arrives. It is nil when any property may have changed.
+	UpdatedProperties []string `json:"updatedProperties"`
+}
+
+// Changes sends a Mailbox/changes call in its own request.
+// Use Type.Sync to page until caught up.
+func Changes(ctx context.Context, c *client.Client, acctID string, args *requests.ChangesArgs) (*ChangesResponse, error) {
+	call, err := Type.ChangesCall(acctID, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct Mailbox/changes call: %w", err)
+	}
+	return requests.SendCall[ChangesResponse](ctx, c, call)
 }
 
 func GetMailboxByName(c *client.Client, name string) (*Mailbox, error) {
+	return GetMailboxByNameWithContext(context.Background(), c, name)
+}
+
+// GetMailboxByNameWithContext is like GetMailboxByName, but the request is bound to ctx.
+func GetMailboxByNameWithContext(ctx context.Context, c *client.Client, name string) (*Mailbox, error) {
 	m := Mailbox{
 		Name: name,
 	}
-	call, err := m.Query(c.Session.PrimaryAccounts.Mail)
+	call, err := m.Query(c.MailAccountID())
 	if err != nil {
 		return nil, fmt.Errorf("failed to construct mailbox query call")
 	}
-	responses, err := requests.Request(c, []*requests.Call{call}, false)
+	responses, err := requests.RequestWithContext(ctx, c, []*requests.Call{call}, false)
 	if err != nil {
 		return nil, fmt.Errorf("query request failure: %w", err)
 	}
@@ -36,26 +116,21 @@ func GetMailboxByName(c *client.Client, name string) (*Mailbox, error) {
 }
 
 func (m *Mailbox) Query(acctID string) (*requests.Call, error) {
-	id, err := uuid.NewRandom()
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate new uuid: %w", err)
-	}
-	return &requests.Call{
-		ID:        id,
-		AccountID: acctID,
-		Method:    "Mailbox/query",
-		Arguments: map[string]any{
-			"filter": map[string]string{
-				"name": m.Name,
-			},
-		},
-		OnSuccess: func(gotMap map[string]any) error {
-			ids, err := parse.QueryResponseBody(gotMap)
-			if err != nil {
-				return fmt.Errorf("failed to parse query response: %w", err)
-			}
-			m.ID = ids[0]
-			return nil
+	call, err := Type.QueryCall(acctID, &requests.QueryArgs{
+		Filter: map[string]string{
+			"name": m.Name,
 		},
-	}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	call.OnSuccess = func(body json.RawMessage) error {
+		ids, err := parse.QueryResponseBody(body)
+		if err != nil {
+			return fmt.Errorf("failed to parse query response: %w", err)
+		}
+		m.ID = ids[0]
+		return nil
+	}
+	return call, nil
 }
@@ -1,11 +1,14 @@
 package mailboxes_test
 
 import (
+	"context"
+	"encoding/json"
 	"testing"
 
+	"github.com/cwinters8/gomap/internal/jmaptest"
 	"github.com/cwinters8/gomap/objects/mailboxes"
+	"github.com/cwinters8/gomap/requests"
 	"github.com/cwinters8/gomap/utils"
-	"github.com/google/uuid"
 )
 
 func TestQueryCall(t *testing.T) {
@@ -22,7 +25,7 @@ func TestQueryCall(t *testing.T) {
 		"wanted account id %s; got %s",
 		acctID, call.AccountID,
 	), utils.NewCase(
-		call.ID == uuid.Nil,
+		len(call.ID) < 1,
 		"wanted call id to be non-nil",
 	)}
 	filter, ok := call.Arguments["filter"].(map[string]string)
@@ -38,3 +41,64 @@ func TestQueryCall(t *testing.T) {
 		t.Error(c.Message)
 	})
 }
+
+func TestChanges(t *testing.T) {
+	c := jmaptest.NewServer(t, func(method string, args map[string]any) (string, map[string]any) {
+		return method, map[string]any{
+			"accountId":         "u1",
+			"oldState":          "1",
+			"newState":          "2",
+			"hasMoreChanges":    false,
+			"created":           []any{},
+			"updated":           []any{"MB1"},
+			"destroyed":         []any{},
+			"updatedProperties": []any{"totalEmails", "unreadEmails"},
+		}
+	}).NewClient()
+	resp, err := mailboxes.Changes(context.Background(), c, "u1", &requests.ChangesArgs{SinceState: "1"})
+	if err != nil {
+		t.Fatalf("failed to get mailbox changes: %s", err.Error())
+	}
+	cases := utils.Cases{
+		utils.NewCase(resp.NewState != "2", "wanted new state 2; got %s", resp.NewState),
+		utils.NewCase(len(resp.Updated) != 1, "wanted 1 updated mailbox; got %v", resp.Updated),
+		utils.NewCase(len(resp.UpdatedProperties) != 2, "wanted 2 updated properties; got %v", resp.UpdatedProperties),
+	}
+	cases.Iterator(func(c *utils.Case) {
+		t.Error(c.Message)
+	})
+}
+
+func TestGetMailboxes(t *testing.T) {
+	var ids any
+	c := jmaptest.NewServer(t, func(method string, args map[string]any) (string, map[string]any) {
+		ids = args["ids"]
+		var resp map[string]any
+		json.Unmarshal([]byte(`{"accountId":"u1","state":"1","list":[
+			{"id":"MB1","name":"Inbox","parentId":null,"role":"inbox","sortOrder":1,"totalEmails":10,"unreadEmails":3,"totalThreads":8,"unreadThreads":2,
+			 "myRights":{"mayReadItems":true,"mayAddItems":true,"mayRemoveItems":true,"maySetSeen":true,"maySetKeywords":true,"mayCreateChild":true,"mayRename":false,"mayDelete":false,"maySubmit":true},"isSubscribed":true},
+			{"id":"MB2","name":"Receipts","parentId":"MB1","role":null,"sortOrder":0,"totalEmails":0,"unreadEmails":0,"totalThreads":0,"unreadThreads":0,"myRights":{"mayReadItems":true},"isSubscribed":false}
+		],"notFound":[]}`), &resp)
+		return method, resp
+	}).NewClient()
+	found, notFound, err := mailboxes.GetMailboxes(c, nil)
+	if err != nil {
+		t.Fatalf("failed to get mailboxes: %s", err.Error())
+	}
+	if len(found) != 2 {
+		t.Fatalf("wanted 2 mailboxes; got %d", len(found))
+	}
+	inbox, receipts := found[0], found[1]
+	cases := utils.Cases{
+		utils.NewCase(ids != nil, "wanted ids to be sent as null; got %v", ids),
+		utils.NewCase(len(notFound) != 0, "wanted no mailboxes not found; got %v", notFound),
+		utils.NewCase(inbox.Role != mailboxes.RoleInbox || inbox.ParentID != "", "wanted a top level inbox; got %+v", inbox),
+		utils.NewCase(inbox.UnreadEmails != 3 || inbox.TotalThreads != 8 || inbox.SortOrder != 1, "wanted inbox counts; got %+v", inbox),
+		utils.NewCase(inbox.MyRights == nil || !inbox.MyRights.MaySubmit || inbox.MyRights.MayDelete, "wanted inbox rights; got %+v", inbox.MyRights),
+		utils.NewCase(!inbox.IsSubscribed, "wanted inbox to be subscribed"),
+		utils.NewCase(receipts.ParentID != "MB1" || receipts.Role != "", "wanted receipts under MB1 without a role; got %+v", receipts),
+	}
+	cases.Iterator(func(c *utils.Case) {
+		t.Error(c.Message)
+	})
+}
@@ -0,0 +1,63 @@
+package mailboxes
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cwinters8/gomap/client"
+)
+
+// RoleNotFoundError reports that an account has no mailbox with Role.
+type RoleNotFoundError struct {
+	Role Role
+}
+
+func (e *RoleNotFoundError) Error() string {
+	return fmt.Sprintf("no mailbox has the `%s` role", e.Role)
+}
+
+// FindByRole returns the mailbox in boxes with role, or a *RoleNotFoundError.
+func FindByRole(boxes []*Mailbox, role Role) (*Mailbox, error) {
+	for _, m := range boxes {
+		if m.Role == role {
+			return m, nil
+		}
+	}
+	return nil, &RoleNotFoundError{Role: role}
+}
+
+// FindByName returns the mailbox in boxes named name, preferring a top level mailbox
+// when several mailboxes share the name.
+func FindByName(boxes []*Mailbox, name string) (*Mailbox, error) {
+	var found *Mailbox
+	for _, m := range boxes {
+		if m.Name != name {
+			continue
+		}
+		if len(m.ParentID) < 1 {
+			return m, nil
+		}
+		if found == nil {
+			found = m
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("no mailbox is named `%s`", name)
+	}
+	return found, nil
+}
+
+// GetMailboxByRole retrieves the mailbox with role from the client's mail account.
+// Unlike names, roles don't depend on the account's language or on the user renaming mailboxes.
+func GetMailboxByRole(c *client.Client, role Role) (*Mailbox, error) {
+	return GetMailboxByRoleWithContext(context.Background(), c, role)
+}
+
+// GetMailboxByRoleWithContext is like GetMailboxByRole, but the request is bound to ctx.
+func GetMailboxByRoleWithContext(ctx context.Context, c *client.Client, role Role) (*Mailbox, error) {
+	boxes, _, err := GetMailboxesWithContext(ctx, c, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mailboxes: %w", err)
+	}
+	return FindByRole(boxes, role)
+}
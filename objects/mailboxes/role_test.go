@@ -0,0 +1,39 @@
+package mailboxes_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cwinters8/gomap/objects/mailboxes"
+	"github.com/cwinters8/gomap/utils"
+)
+
+func TestFind(t *testing.T) {
+	boxes := []*mailboxes.Mailbox{
+		{ID: "MB1", Name: "Posteingang", Role: mailboxes.RoleInbox},
+		{ID: "MB2", Name: "Entwürfe", Role: mailboxes.RoleDrafts},
+		{ID: "MB3", Name: "Archiv", ParentID: "MB1"},
+		{ID: "MB4", Name: "Archiv"},
+	}
+	drafts, err := mailboxes.FindByRole(boxes, mailboxes.RoleDrafts)
+	if err != nil {
+		t.Fatalf("failed to find drafts: %s", err.Error())
+	}
+	archive, err := mailboxes.FindByName(boxes, "Archiv")
+	if err != nil {
+		t.Fatalf("failed to find archive: %s", err.Error())
+	}
+	_, err = mailboxes.FindByRole(boxes, mailboxes.RoleSent)
+	var roleErr *mailboxes.RoleNotFoundError
+	cases := utils.Cases{
+		utils.NewCase(drafts.ID != "MB2", "wanted drafts MB2; got %s", drafts.ID),
+		utils.NewCase(archive.ID != "MB4", "wanted the top level archive MB4; got %s", archive.ID),
+		utils.NewCase(!errors.As(err, &roleErr) || roleErr.Role != mailboxes.RoleSent, "wanted a missing sent role; got %v", err),
+	}
+	if _, err := mailboxes.FindByName(boxes, "Drafts"); err == nil {
+		cases.Append(utils.NewCase(true, "wanted an error for a missing name"))
+	}
+	cases.Iterator(func(c *utils.Case) {
+		t.Error(c.Message)
+	})
+}
@@ -0,0 +1,165 @@
+package mailboxes
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cwinters8/gomap/client"
+	"github.com/cwinters8/gomap/requests"
+)
+
+// Mailbox/set errors beyond the standard ones (RFC 8621 section 2.5).
+const (
+	SetErrorMailboxHasChild requests.SetErrorType = "mailboxHasChild" // the mailbox has children, which must be moved or destroyed first
+	SetErrorMailboxHasEmail requests.SetErrorType = "mailboxHasEmail" // the mailbox has emails and OnDestroyRemoveEmails wasn't set
+)
+
+// Update is a change to a mailbox. Nil fields are left as they are.
+type Update struct {
+	Name         *string
+	ParentID     *string // an empty id moves the mailbox to the top level
+	Role         *Role   // an empty role removes it
+	SortOrder    *int
+	IsSubscribed *bool
+}
+
+func (u *Update) patch() requests.Patch {
+	p := requests.Patch{}
+	if u.Name != nil {
+		p["name"] = *u.Name
+	}
+	if u.ParentID != nil {
+		p["parentId"] = nullable(*u.ParentID)
+	}
+	if u.Role != nil {
+		p["role"] = nullable(string(*u.Role))
+	}
+	if u.SortOrder != nil {
+		p["sortOrder"] = *u.SortOrder
+	}
+	if u.IsSubscribed != nil {
+		p["isSubscribed"] = *u.IsSubscribed
+	}
+	return p
+}
+
+// SetArgs are the arguments of Mailbox/set.
+type SetArgs struct {
+	IfInState string
+	// Create is keyed by creation id. Only the name, parent, role, sort order
+	// and subscription of each mailbox are sent; the rest are set by the server.
+	Create  map[string]*Mailbox
+	Update  map[string]*Update
+	Destroy []string
+	// OnDestroyRemoveEmails removes the emails in destroyed mailboxes, destroying those that
+	// aren't in any other mailbox. Otherwise mailboxes with emails aren't destroyed.
+	OnDestroyRemoveEmails bool
+}
+
+// creation holds the properties of a mailbox that the client may set.
+type creation struct {
+	Name         string `json:"name"`
+	ParentID     any    `json:"parentId"`
+	Role         any    `json:"role"`
+	SortOrder    int    `json:"sortOrder"`
+	IsSubscribed bool   `json:"isSubscribed"`
+}
+
+// creations builds Mailbox/set calls, which send only the properties of creation.
+var creations = requests.Type[creation]{Name: Type.Name}
+
+// SetCall constructs a Mailbox/set call.
+func SetCall(acctID string, args *SetArgs) (*requests.Call, error) {
+	if args == nil {
+		args = &SetArgs{}
+	}
+	setArgs := requests.SetArgs[creation]{IfInState: args.IfInState, Destroy: args.Destroy}
+	if len(args.Create) > 0 {
+		setArgs.Create = map[string]*creation{}
+		for id, m := range args.Create {
+			if len(m.Name) < 1 {
+				return nil, fmt.Errorf("mailbox %s has no name", id)
+			}
+			setArgs.Create[id] = &creation{
+				Name:         m.Name,
+				ParentID:     nullable(m.ParentID),
+				Role:         nullable(string(m.Role)),
+				SortOrder:    m.SortOrder,
+				IsSubscribed: m.IsSubscribed,
+			}
+		}
+	}
+	if len(args.Update) > 0 {
+		setArgs.Update = map[string]requests.Patch{}
+		for id, u := range args.Update {
+			setArgs.Update[id] = u.patch()
+		}
+	}
+	if args.OnDestroyRemoveEmails {
+		setArgs.Extra = map[string]any{"onDestroyRemoveEmails": true}
+	}
+	return creations.SetCall(acctID, &setArgs)
+}
+
+// Set creates, updates and destroys mailboxes in the account with acctID.
+//
+// Mailboxes that couldn't be changed are listed in the response with a *requests.SetError,
+// such as SetErrorMailboxHasChild; the response's Err joins them into one error.
+func Set(ctx context.Context, c *client.Client, acctID string, args *SetArgs) (*requests.SetResponse[Mailbox], error) {
+	call, err := SetCall(acctID, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct Mailbox/set call: %w", err)
+	}
+	return requests.SendCall[requests.SetResponse[Mailbox]](ctx, c, call)
+}
+
+// Create creates m in the client's mail account, filling in its id and server set properties.
+func Create(ctx context.Context, c *client.Client, m *Mailbox) error {
+	resp, err := Set(ctx, c, c.MailAccountID(), &SetArgs{Create: map[string]*Mailbox{"mailbox": m}})
+	if err != nil {
+		return err
+	}
+	if err := resp.Err(); err != nil {
+		return err
+	}
+	created, ok := resp.Created["mailbox"]
+	if !ok {
+		return fmt.Errorf("mailbox %s was not created", m.Name)
+	}
+	m.ID = created.ID
+	m.TotalEmails, m.UnreadEmails = created.TotalEmails, created.UnreadEmails
+	m.TotalThreads, m.UnreadThreads = created.TotalThreads, created.UnreadThreads
+	if created.MyRights != nil {
+		m.MyRights = created.MyRights
+	}
+	return nil
+}
+
+// Modify applies u to the mailbox with id in the client's mail account,
+// such as renaming it or moving it under another parent.
+func Modify(ctx context.Context, c *client.Client, id string, u *Update) error {
+	resp, err := Set(ctx, c, c.MailAccountID(), &SetArgs{Update: map[string]*Update{id: u}})
+	if err != nil {
+		return err
+	}
+	return resp.Err()
+}
+
+// Destroy destroys the mailbox with id in the client's mail account.
+// With removeEmails, its emails are removed from it first; otherwise a mailbox
+// with emails fails with SetErrorMailboxHasEmail.
+func Destroy(ctx context.Context, c *client.Client, id string, removeEmails bool) error {
+	resp, err := Set(ctx, c, c.MailAccountID(), &SetArgs{Destroy: []string{id}, OnDestroyRemoveEmails: removeEmails})
+	if err != nil {
+		return err
+	}
+	return resp.Err()
+}
+
+// nullable returns nil for an empty string, which is sent as null.
+func nullable(s string) any {
+	if len(s) < 1 {
+		return nil
+	}
+	return s
+}
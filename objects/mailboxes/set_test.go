@@ -0,0 +1,106 @@
+package mailboxes_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cwinters8/gomap/internal/jmaptest"
+	"github.com/cwinters8/gomap/objects/mailboxes"
+	"github.com/cwinters8/gomap/requests"
+	"github.com/cwinters8/gomap/utils"
+)
+
+func TestSet(t *testing.T) {
+	var args map[string]any
+	c := jmaptest.NewServer(t, func(method string, a map[string]any) (string, map[string]any) {
+		args = a
+		resp := map[string]any{"accountId": "u1", "oldState": "1", "newState": "2"}
+		if create, ok := args["create"].(map[string]any); ok {
+			for id := range create {
+				resp["created"] = map[string]any{id: map[string]any{"id": "MB9", "totalEmails": 0, "myRights": map[string]any{"mayRename": true}}}
+			}
+		}
+		if update, ok := args["update"].(map[string]any); ok {
+			for id := range update {
+				resp["updated"] = map[string]any{id: nil}
+			}
+		}
+		if _, ok := args["destroy"]; ok {
+			resp["notDestroyed"] = map[string]any{
+				"MB1": map[string]any{"type": "mailboxHasChild"},
+				"MB2": map[string]any{"type": "mailboxHasEmail"},
+			}
+		}
+		return method, resp
+	}).NewClient()
+	ctx := context.Background()
+
+	t.Run("create", func(t *testing.T) {
+		m := mailboxes.Mailbox{Name: "Receipts", ParentID: "MB1", IsSubscribed: true}
+		if err := mailboxes.Create(ctx, c, &m); err != nil {
+			t.Fatalf("failed to create mailbox: %s", err.Error())
+		}
+		sent := args["create"].(map[string]any)["mailbox"].(map[string]any)
+		_, id := sent["id"]
+		_, total := sent["totalEmails"]
+		role, hasRole := sent["role"]
+		cases := utils.Cases{
+			utils.NewCase(m.ID != "MB9", "wanted mailbox id MB9; got %s", m.ID),
+			utils.NewCase(m.MyRights == nil || !m.MyRights.MayRename, "wanted rights from the server; got %v", m.MyRights),
+			utils.NewCase(sent["name"] != "Receipts" || sent["parentId"] != "MB1" || sent["isSubscribed"] != true, "wanted the mailbox's properties; got %v", sent),
+			utils.NewCase(id || total, "wanted server set properties to be left out; got %v", sent),
+			utils.NewCase(!hasRole || role != nil, "wanted a null role; got %v", sent),
+		}
+		cases.Iterator(func(c *utils.Case) {
+			t.Error(c.Message)
+		})
+	})
+
+	t.Run("update", func(t *testing.T) {
+		top, order := "", 3
+		if err := mailboxes.Modify(ctx, c, "MB9", &mailboxes.Update{ParentID: &top, SortOrder: &order}); err != nil {
+			t.Fatalf("failed to update mailbox: %s", err.Error())
+		}
+		patch := args["update"].(map[string]any)["MB9"].(map[string]any)
+		parent, moved := patch["parentId"]
+		_, renamed := patch["name"]
+		cases := utils.Cases{
+			utils.NewCase(!moved || parent != nil, "wanted the mailbox moved to the top level; got %v", patch),
+			utils.NewCase(patch["sortOrder"] != float64(3), "wanted sort order 3; got %v", patch),
+			utils.NewCase(renamed, "wanted the name left unchanged; got %v", patch),
+		}
+		cases.Iterator(func(c *utils.Case) {
+			t.Error(c.Message)
+		})
+	})
+
+	t.Run("destroy", func(t *testing.T) {
+		resp, err := mailboxes.Set(ctx, c, "u1", &mailboxes.SetArgs{Destroy: []string{"MB1", "MB2"}, OnDestroyRemoveEmails: true})
+		if err != nil {
+			t.Fatalf("failed to destroy mailboxes: %s", err.Error())
+		}
+		err = resp.Err()
+		var objErr *requests.ObjectError
+		var setErr *requests.SetError
+		cases := utils.Cases{
+			utils.NewCase(args["onDestroyRemoveEmails"] != true, "wanted onDestroyRemoveEmails; got %v", args),
+			utils.NewCase(resp.NotDestroyed["MB1"] == nil || resp.NotDestroyed["MB1"].Type != mailboxes.SetErrorMailboxHasChild, "wanted MB1 to have a child; got %v", resp.NotDestroyed),
+			utils.NewCase(resp.NotDestroyed["MB2"] == nil || resp.NotDestroyed["MB2"].Type != mailboxes.SetErrorMailboxHasEmail, "wanted MB2 to have emails; got %v", resp.NotDestroyed),
+			utils.NewCase(!errors.As(err, &objErr) || objErr.ID != "MB1" || objErr.Op != "destroy", "wanted an object error for MB1; got %v", err),
+			utils.NewCase(!errors.As(err, &setErr) || setErr.Type != mailboxes.SetErrorMailboxHasChild, "wanted a mailboxHasChild set error; got %v", err),
+		}
+		cases.Iterator(func(c *utils.Case) {
+			t.Error(c.Message)
+		})
+		if err := mailboxes.Destroy(ctx, c, "MB1", false); err == nil {
+			t.Error("wanted an error destroying a mailbox with children")
+		}
+	})
+
+	t.Run("nothing to do", func(t *testing.T) {
+		if _, err := mailboxes.SetCall("u1", nil); err == nil {
+			t.Error("wanted an error for a set call without changes")
+		}
+	})
+}
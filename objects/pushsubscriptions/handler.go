@@ -0,0 +1,129 @@
+package pushsubscriptions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/cwinters8/gomap/client"
+)
+
+// Verification is the PushVerification object the server POSTs to a new push subscription's url.
+type Verification struct {
+	Type               string `json:"@type"`
+	PushSubscriptionID string `json:"pushSubscriptionId"`
+	VerificationCode   string `json:"verificationCode"`
+}
+
+// Handler receives pushes at a push subscription's url.
+//
+// It completes the verification of new subscriptions with Client and hands every
+// StateChange to OnStateChange. When Keys is set, bodies sent with the aes128gcm
+// content encoding are decrypted first.
+//
+//	keys, _ := pushsubscriptions.NewKeys()
+//	http.Handle("/jmap/push", &pushsubscriptions.Handler{
+//		Client:        c,
+//		Keys:          keys,
+//		OnStateChange: func(s *client.StateChange) { ... },
+//	})
+//	sub := pushsubscriptions.PushSubscription{
+//		DeviceClientID: "worker-1",
+//		URL:            "https://example.com/jmap/push",
+//		Keys:           keys.Public(),
+//	}
+//	err := pushsubscriptions.Create(ctx, c, &sub)
+type Handler struct {
+	Client        *client.Client
+	Keys          *Keys
+	OnStateChange func(*client.StateChange)
+	// OnError is called with errors that can't be reported to the server,
+	// such as a failure to complete verification. Errors are dropped when it is nil.
+	OnError func(error)
+	// MaxBodySize limits the size of a push; 64KiB when zero.
+	MaxBodySize int64
+}
+
+// verifyTimeout bounds the request that completes a verification.
+const verifyTimeout = 30 * time.Second
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	limit := h.MaxBodySize
+	if limit <= 0 {
+		limit = 64 * 1024
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if r.Header.Get("Content-Encoding") == "aes128gcm" {
+		if h.Keys == nil {
+			http.Error(w, "encrypted pushes are not supported", http.StatusUnsupportedMediaType)
+			return
+		}
+		body, err = h.Keys.Decrypt(body)
+		if err != nil {
+			h.fail(fmt.Errorf("failed to decrypt push: %w", err))
+			http.Error(w, "failed to decrypt body", http.StatusBadRequest)
+			return
+		}
+	}
+	var msg struct {
+		Type string `json:"@type"`
+	}
+	if err := json.Unmarshal(body, &msg); err != nil {
+		http.Error(w, "body must be a JSON object", http.StatusBadRequest)
+		return
+	}
+	switch msg.Type {
+	case "PushVerification":
+		var v Verification
+		if err := json.Unmarshal(body, &v); err != nil {
+			http.Error(w, "invalid PushVerification", http.StatusBadRequest)
+			return
+		}
+		// the server may wait for this response before it accepts the code,
+		// so verification is completed in the background
+		go h.verify(&v)
+	case "StateChange":
+		var change client.StateChange
+		if err := json.Unmarshal(body, &change); err != nil {
+			http.Error(w, "invalid StateChange", http.StatusBadRequest)
+			return
+		}
+		if h.OnStateChange != nil {
+			h.OnStateChange(&change)
+		}
+	default:
+		http.Error(w, fmt.Sprintf("unexpected push type `%s`", msg.Type), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) verify(v *Verification) {
+	if h.Client == nil {
+		h.fail(fmt.Errorf("no client to verify push subscription %s with", v.PushSubscriptionID))
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), verifyTimeout)
+	defer cancel()
+	if err := Verify(ctx, h.Client, v.PushSubscriptionID, v.VerificationCode); err != nil {
+		h.fail(fmt.Errorf("failed to verify push subscription %s: %w", v.PushSubscriptionID, err))
+	}
+}
+
+func (h *Handler) fail(err error) {
+	if h.OnError != nil {
+		h.OnError(err)
+	}
+}
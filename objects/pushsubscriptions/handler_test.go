@@ -0,0 +1,126 @@
+package pushsubscriptions_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cwinters8/gomap/client"
+	"github.com/cwinters8/gomap/internal/jmaptest"
+	"github.com/cwinters8/gomap/objects/pushsubscriptions"
+	"github.com/cwinters8/gomap/utils"
+)
+
+// fakeAPI answers PushSubscription/set, sending the arguments of every call to calls.
+func fakeAPI(t *testing.T, calls chan<- map[string]any) *client.Client {
+	return jmaptest.NewServer(t, func(method string, args map[string]any) (string, map[string]any) {
+		calls <- args
+		if _, ok := args["create"]; ok {
+			return method, map[string]any{"created": map[string]any{"push": map[string]any{"id": "P1", "expires": "2030-01-01T00:00:00Z"}}}
+		}
+		return method, map[string]any{"updated": map[string]any{"P1": nil}}
+	}).NewClient()
+}
+
+func TestCreate(t *testing.T) {
+	calls := make(chan map[string]any, 1)
+	c := fakeAPI(t, calls)
+	keys, err := pushsubscriptions.NewKeys()
+	if err != nil {
+		t.Fatalf("failed to generate keys: %s", err.Error())
+	}
+	sub := pushsubscriptions.PushSubscription{
+		DeviceClientID: "worker-1",
+		URL:            "https://example.com/push",
+		Keys:           keys.Public(),
+		Types:          []string{"Email"},
+	}
+	if err := pushsubscriptions.Create(context.Background(), c, &sub); err != nil {
+		t.Fatalf("failed to create push subscription: %s", err.Error())
+	}
+	args := <-calls
+	_, hasAccount := args["accountId"]
+	created, _ := args["create"].(map[string]any)["push"].(map[string]any)
+	cases := utils.Cases{
+		utils.NewCase(sub.ID != "P1", "wanted id P1; got %s", sub.ID),
+		utils.NewCase(sub.Expires == nil || sub.Expires.Year() != 2030, "wanted expiry from the server; got %v", sub.Expires),
+		utils.NewCase(hasAccount, "wanted no accountId argument"),
+		utils.NewCase(created["url"] != sub.URL, "wanted url %s; got %v", sub.URL, created["url"]),
+		utils.NewCase(created["keys"] == nil, "wanted keys to be sent"),
+	}
+	cases.Iterator(func(c *utils.Case) {
+		t.Error(c.Message)
+	})
+}
+
+func TestHandler(t *testing.T) {
+	calls := make(chan map[string]any, 1)
+	changes := make(chan *client.StateChange, 1)
+	h := pushsubscriptions.Handler{
+		Client: fakeAPI(t, calls),
+		OnStateChange: func(s *client.StateChange) {
+			changes <- s
+		},
+		OnError: func(err error) {
+			t.Errorf("handler error: %s", err.Error())
+		},
+	}
+	post := func(body string, encoding string) int {
+		r := httptest.NewRequest(http.MethodPost, "/push", strings.NewReader(body))
+		if len(encoding) > 0 {
+			r.Header.Set("Content-Encoding", encoding)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	t.Run("verification", func(t *testing.T) {
+		status := post(`{"@type":"PushVerification","pushSubscriptionId":"P1","verificationCode":"abc"}`, "")
+		if status != http.StatusOK {
+			t.Fatalf("wanted status 200; got %d", status)
+		}
+		select {
+		case args := <-calls:
+			code := args["update"].(map[string]any)["P1"].(map[string]any)["verificationCode"]
+			if code != "abc" {
+				t.Errorf("wanted verification code abc; got %v", code)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for verification")
+		}
+	})
+
+	t.Run("state change", func(t *testing.T) {
+		status := post(`{"@type":"StateChange","changed":{"u1":{"Email":"s9"}}}`, "")
+		if status != http.StatusOK {
+			t.Fatalf("wanted status 200; got %d", status)
+		}
+		state, _ := (<-changes).State("u1", "Email")
+		if state != "s9" {
+			t.Errorf("wanted state s9; got %s", state)
+		}
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		cases := utils.Cases{}
+		if status := post(`{"@type":"Other"}`, ""); status != http.StatusBadRequest {
+			cases.Append(utils.NewCase(true, "wanted unknown types to be rejected; got %d", status))
+		}
+		if status := post("encrypted", "aes128gcm"); status != http.StatusUnsupportedMediaType {
+			cases.Append(utils.NewCase(true, "wanted encrypted pushes to be rejected without keys; got %d", status))
+		}
+		r := httptest.NewRequest(http.MethodGet, "/push", nil)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != http.StatusMethodNotAllowed {
+			cases.Append(utils.NewCase(true, "wanted GET to be rejected; got %d", w.Code))
+		}
+		cases.Iterator(func(c *utils.Case) {
+			t.Error(c.Message)
+		})
+	})
+}
@@ -0,0 +1,149 @@
+package pushsubscriptions
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+)
+
+// Keys are the private half of the keys a push subscription is registered with,
+// used to decrypt what the server pushes (RFC 8291).
+//
+// Keys must outlive the process for as long as the subscription does,
+// so store Private and AuthSecret and restore them with LoadKeys.
+type Keys struct {
+	private *ecdh.PrivateKey
+	auth    []byte
+}
+
+// NewKeys generates a new P-256 key pair and authentication secret.
+func NewKeys() (*Keys, error) {
+	private, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	auth := make([]byte, 16)
+	if _, err := rand.Read(auth); err != nil {
+		return nil, fmt.Errorf("failed to generate auth secret: %w", err)
+	}
+	return &Keys{private: private, auth: auth}, nil
+}
+
+// LoadKeys restores keys from the values returned by Private and AuthSecret.
+func LoadKeys(private, authSecret []byte) (*Keys, error) {
+	key, err := ecdh.P256().NewPrivateKey(private)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	if len(authSecret) != 16 {
+		return nil, fmt.Errorf("auth secret must be 16 bytes; got %d", len(authSecret))
+	}
+	return &Keys{private: key, auth: authSecret}, nil
+}
+
+// Private returns the private key.
+func (k *Keys) Private() []byte {
+	return k.private.Bytes()
+}
+
+// AuthSecret returns the authentication secret.
+func (k *Keys) AuthSecret() []byte {
+	return k.auth
+}
+
+// Public returns the keys to register a push subscription with.
+func (k *Keys) Public() *PublicKey {
+	return &PublicKey{
+		P256DH: base64.RawURLEncoding.EncodeToString(k.private.PublicKey().Bytes()),
+		Auth:   base64.RawURLEncoding.EncodeToString(k.auth),
+	}
+}
+
+// Decrypt decrypts a body encrypted with the aes128gcm content encoding (RFC 8188)
+// using keys derived as described in RFC 8291.
+func (k *Keys) Decrypt(body []byte) ([]byte, error) {
+	// header: salt (16) | record size (4) | key id length (1) | key id, the sender's public key
+	if len(body) < 21 {
+		return nil, fmt.Errorf("encrypted body is too short")
+	}
+	salt := body[:16]
+	rs := int(binary.BigEndian.Uint32(body[16:20]))
+	idLen := int(body[20])
+	if len(body) < 21+idLen {
+		return nil, fmt.Errorf("encrypted body is too short for its key id")
+	}
+	if rs < 18 {
+		return nil, fmt.Errorf("invalid record size %d", rs)
+	}
+	sender, err := ecdh.P256().NewPublicKey(body[21 : 21+idLen])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse sender public key: %w", err)
+	}
+	secret, err := k.private.ECDH(sender)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute shared secret: %w", err)
+	}
+
+	keyInfo := append([]byte("WebPush: info\x00"), k.private.PublicKey().Bytes()...)
+	keyInfo = append(keyInfo, sender.Bytes()...)
+	ikm := hkdf(k.auth, secret, keyInfo, 32)
+	cek := hkdf(salt, ikm, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	nonce := hkdf(salt, ikm, []byte("Content-Encoding: nonce\x00"), 12)
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gcm: %w", err)
+	}
+	records := body[21+idLen:]
+	var plain []byte
+	for seq := uint64(0); len(records) > 0; seq++ {
+		n := rs
+		if n > len(records) {
+			n = len(records)
+		}
+		recordNonce := make([]byte, len(nonce))
+		copy(recordNonce, nonce)
+		for i := 0; i < 8; i++ {
+			recordNonce[len(recordNonce)-1-i] ^= byte(seq >> (8 * i))
+		}
+		record, err := gcm.Open(nil, recordNonce, records[:n], nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt record %d: %w", seq, err)
+		}
+		records = records[n:]
+		// each record ends with a delimiter, 2 for the last record and 1 for the others, then zero padding
+		record = bytes.TrimRight(record, "\x00")
+		if len(record) < 1 {
+			return nil, fmt.Errorf("record %d has no padding delimiter", seq)
+		}
+		last := len(records) == 0
+		switch delimiter := record[len(record)-1]; {
+		case last && delimiter != 2, !last && delimiter != 1:
+			return nil, fmt.Errorf("record %d has an invalid padding delimiter", seq)
+		}
+		plain = append(plain, record[:len(record)-1]...)
+	}
+	return plain, nil
+}
+
+// hkdf derives length bytes from ikm with HKDF-SHA-256 (RFC 5869), for lengths of at most 32.
+func hkdf(salt, ikm, info []byte, length int) []byte {
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(ikm)
+	prk := extract.Sum(nil)
+	expand := hmac.New(sha256.New, prk)
+	expand.Write(info)
+	expand.Write([]byte{1})
+	return expand.Sum(nil)[:length]
+}
@@ -0,0 +1,57 @@
+package pushsubscriptions_test
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/cwinters8/gomap/objects/pushsubscriptions"
+	"github.com/cwinters8/gomap/utils"
+)
+
+// the example from RFC 8291 appendix A
+const (
+	examplePrivate = "q1dXpw3UpT5VOmu_cf_v6ih07Aems3njxI-JWgLcM94"
+	examplePublic  = "BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4"
+	exampleAuth    = "BTBZMqHH6r4Tts7J_aSIgg"
+	exampleBody    = "DGv6ra1nlYgDCS1FRnbzlwAAEABBBP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A_yl95bQpu6cVPTpK4Mqgkf1CXztLVBSt2Ks3oZwbuwXPXLWyouBWLVWGNWQexSgSxsj_Qulcy4a-fN"
+)
+
+func decode(t *testing.T, s string) []byte {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		t.Fatalf("failed to decode %s: %s", s, err.Error())
+	}
+	return b
+}
+
+func TestDecrypt(t *testing.T) {
+	keys, err := pushsubscriptions.LoadKeys(decode(t, examplePrivate), decode(t, exampleAuth))
+	if err != nil {
+		t.Fatalf("failed to load keys: %s", err.Error())
+	}
+	plain, err := keys.Decrypt(decode(t, exampleBody))
+	if err != nil {
+		t.Fatalf("failed to decrypt: %s", err.Error())
+	}
+	public := keys.Public()
+	cases := utils.Cases{
+		utils.NewCase(string(plain) != "When I grow up, I want to be a watermelon", "unexpected plaintext %q", plain),
+		utils.NewCase(public.P256DH != examplePublic, "wanted public key %s; got %s", examplePublic, public.P256DH),
+		utils.NewCase(public.Auth != exampleAuth, "wanted auth secret %s; got %s", exampleAuth, public.Auth),
+	}
+	tampered := decode(t, exampleBody)
+	tampered[len(tampered)-1] ^= 1
+	if _, err := keys.Decrypt(tampered); err == nil {
+		cases.Append(utils.NewCase(true, "wanted tampered body to fail decryption"))
+	}
+	generated, err := pushsubscriptions.NewKeys()
+	if err != nil {
+		t.Fatalf("failed to generate keys: %s", err.Error())
+	}
+	if _, err := generated.Decrypt(decode(t, exampleBody)); err == nil {
+		cases.Append(utils.NewCase(true, "wanted decryption with the wrong keys to fail"))
+	}
+	cases.Iterator(func(c *utils.Case) {
+		t.Error(c.Message)
+	})
+}
@@ -0,0 +1,117 @@
+package pushsubscriptions
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cwinters8/gomap/client"
+	"github.com/cwinters8/gomap/requests"
+)
+
+// PushSubscription asks the server to POST StateChange objects to URL (RFC 8620 section 7.2).
+//
+// Push subscriptions belong to the authenticated user rather than an account.
+type PushSubscription struct {
+	ID               string     `json:"id,omitempty"`
+	DeviceClientID   string     `json:"deviceClientId,omitempty"`
+	URL              string     `json:"url,omitempty"`
+	Keys             *PublicKey `json:"keys,omitempty"`
+	VerificationCode string     `json:"verificationCode,omitempty"`
+	Expires          *time.Time `json:"expires,omitempty"`
+	Types            []string   `json:"types,omitempty"` // every data type when empty
+}
+
+// PublicKey is what the server needs to encrypt pushed data for us (RFC 8291),
+// with both fields base64url encoded.
+type PublicKey struct {
+	P256DH string `json:"p256dh"`
+	Auth   string `json:"auth"`
+}
+
+// Type provides the standard JMAP methods for push subscriptions.
+// Calls made with it should be given an empty account id.
+var Type = requests.Type[PushSubscription]{Name: "PushSubscription"}
+
+// GetPushSubscriptions retrieves every push subscription of the authenticated user.
+func GetPushSubscriptions(ctx context.Context, c *client.Client) ([]*PushSubscription, error) {
+	resp, err := Type.Get(ctx, c, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.List, nil
+}
+
+// Create registers sub with the server, setting its ID and Expires.
+//
+// The server then POSTs a PushVerification to sub.URL, which must be passed to Verify
+// before anything else is pushed. Handler does this automatically.
+func Create(ctx context.Context, c *client.Client, sub *PushSubscription) error {
+	resp, err := Type.Set(ctx, c, "", &requests.SetArgs[PushSubscription]{
+		Create: map[string]*PushSubscription{"push": sub},
+	})
+	if err != nil {
+		return err
+	}
+	if setErr, ok := resp.NotCreated["push"]; ok {
+		return fmt.Errorf("failed to create push subscription: %w", setErr)
+	}
+	created, ok := resp.Created["push"]
+	if !ok {
+		return fmt.Errorf("push subscription was not created")
+	}
+	sub.ID = created.ID
+	if created.Expires != nil {
+		sub.Expires = created.Expires
+	}
+	return nil
+}
+
+// Verify completes the verification of the push subscription with id,
+// confirming that we received the code the server pushed.
+func Verify(ctx context.Context, c *client.Client, id, code string) error {
+	return update(ctx, c, id, requests.Patch{"verificationCode": code})
+}
+
+// Renew asks the server to keep the push subscription with id until expires.
+// The server may choose an earlier time, which is returned.
+func Renew(ctx context.Context, c *client.Client, id string, expires time.Time) (time.Time, error) {
+	resp, err := Type.Set(ctx, c, "", &requests.SetArgs[PushSubscription]{
+		Update: map[string]requests.Patch{id: {"expires": expires.UTC().Format(time.RFC3339)}},
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	if setErr, ok := resp.NotUpdated[id]; ok {
+		return time.Time{}, fmt.Errorf("failed to renew push subscription %s: %w", id, setErr)
+	}
+	if updated := resp.Updated[id]; updated != nil && updated.Expires != nil {
+		return *updated.Expires, nil
+	}
+	return expires, nil
+}
+
+// Destroy removes the push subscription with id.
+func Destroy(ctx context.Context, c *client.Client, id string) error {
+	resp, err := Type.Set(ctx, c, "", &requests.SetArgs[PushSubscription]{Destroy: []string{id}})
+	if err != nil {
+		return err
+	}
+	if setErr, ok := resp.NotDestroyed[id]; ok {
+		return fmt.Errorf("failed to destroy push subscription %s: %w", id, setErr)
+	}
+	return nil
+}
+
+func update(ctx context.Context, c *client.Client, id string, patch requests.Patch) error {
+	resp, err := Type.Set(ctx, c, "", &requests.SetArgs[PushSubscription]{
+		Update: map[string]requests.Patch{id: patch},
+	})
+	if err != nil {
+		return err
+	}
+	if setErr, ok := resp.NotUpdated[id]; ok {
+		return fmt.Errorf("failed to update push subscription %s: %w", id, setErr)
+	}
+	return nil
+}
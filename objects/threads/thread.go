@@ -0,0 +1,31 @@
+package threads
+
+import (
+	"context"
+
+	"github.com/cwinters8/gomap/client"
+	"github.com/cwinters8/gomap/requests"
+)
+
+// Thread is a conversation, listing its emails oldest first (RFC 8621 section 3).
+type Thread struct {
+	ID       string   `json:"id"`
+	EmailIDs []string `json:"emailIds"`
+}
+
+// Type provides the standard JMAP methods for threads.
+var Type = requests.Type[Thread]{Name: "Thread"}
+
+// GetThreads retrieves the threads with threadIDs from the client's mail account.
+func GetThreads(c *client.Client, threadIDs []string) (found []*Thread, notFound []string, err error) {
+	return GetThreadsWithContext(context.Background(), c, threadIDs)
+}
+
+// GetThreadsWithContext is like GetThreads, but the request is bound to ctx.
+func GetThreadsWithContext(ctx context.Context, c *client.Client, threadIDs []string) (found []*Thread, notFound []string, err error) {
+	resp, err := Type.Get(ctx, c, c.MailAccountID(), &requests.GetArgs{IDs: threadIDs})
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp.List, resp.NotFound, nil
+}
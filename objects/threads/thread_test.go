@@ -0,0 +1,36 @@
+package threads_test
+
+import (
+	"testing"
+
+	"github.com/cwinters8/gomap/internal/jmaptest"
+	"github.com/cwinters8/gomap/objects/threads"
+	"github.com/cwinters8/gomap/utils"
+)
+
+func TestGetThreads(t *testing.T) {
+	c := jmaptest.NewServer(t, func(method string, args map[string]any) (string, map[string]any) {
+		return method, map[string]any{
+			"accountId": "u1",
+			"state":     "1",
+			"list":      []any{map[string]any{"id": "T1", "emailIds": []any{"M1", "M2"}}},
+			"notFound":  []any{"T2"},
+		}
+	}).NewClient()
+	call, err := threads.Type.GetCall("u1", nil)
+	if err != nil {
+		t.Fatalf("failed to construct call: %s", err.Error())
+	}
+	found, notFound, err := threads.GetThreads(c, []string{"T1", "T2"})
+	if err != nil {
+		t.Fatalf("failed to get threads: %s", err.Error())
+	}
+	cases := utils.Cases{
+		utils.NewCase(call.Method != "Thread/get", "wanted method Thread/get; got %s", call.Method),
+		utils.NewCase(len(found) != 1 || len(found[0].EmailIDs) != 2, "wanted 1 thread with 2 emails; got %v", found),
+		utils.NewCase(len(notFound) != 1, "wanted 1 thread not found; got %v", notFound),
+	}
+	cases.Iterator(func(c *utils.Case) {
+		t.Error(c.Message)
+	})
+}
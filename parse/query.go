@@ -5,13 +5,9 @@ import (
 	"fmt"
 )
 
-func QueryResponseBody(body map[string]any) (ids []string, err error) {
-	b, err := json.Marshal(body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal body to json: %w", err)
-	}
+func QueryResponseBody(body json.RawMessage) (ids []string, err error) {
 	var resp queryResponse
-	if err := json.Unmarshal(b, &resp); err != nil {
+	if err := json.Unmarshal(body, &resp); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal query response: %w", err)
 	}
 	return resp.IDs, nil
@@ -0,0 +1,158 @@
+package requests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cwinters8/gomap/client"
+)
+
+// Batch collects calls to be sent together, handing back a typed Handle for each call.
+//
+//	b := requests.NewBatch(c)
+//	query := requests.Add[QueryResult](b, queryCall)
+//	get := requests.Add[GetResult](b, getCall)
+//	if err := b.Send(ctx); err != nil {
+//		return err
+//	}
+//	result, err := get.Result()
+type Batch struct {
+	client  *client.Client
+	calls   []*Call
+	handles []resolver
+	sent    bool
+}
+
+// resolver is implemented by every Handle so a Batch can fill them in without knowing their type.
+type resolver interface {
+	resolve(responses []*Response, errs MethodErrors)
+	fail(err error)
+}
+
+// NewBatch creates an empty Batch that will be sent with c.
+func NewBatch(c *client.Client) *Batch {
+	return &Batch{client: c}
+}
+
+// Add adds call to b, returning a Handle that decodes the call's response into T once b is sent.
+//
+// Add is a function rather than a method of Batch because methods can't have type parameters.
+func Add[T any](b *Batch, call *Call) *Handle[T] {
+	h := Handle[T]{call: call}
+	b.calls = append(b.calls, call)
+	b.handles = append(b.handles, &h)
+	return &h
+}
+
+// Calls returns the calls added to b so far.
+func (b *Batch) Calls() []*Call {
+	return b.calls
+}
+
+// Send sends every call in b with opts and resolves their handles.
+//
+// The returned error only reports failures of the request as a whole.
+// Method errors are reported by the Result of the affected handle,
+// so the other handles can still be read.
+func (b *Batch) Send(ctx context.Context, opts ...Option) error {
+	if b.sent {
+		return fmt.Errorf("batch has already been sent")
+	}
+	if len(b.calls) < 1 {
+		return fmt.Errorf("batch has no calls")
+	}
+	b.sent = true
+	usingSubmission := false
+	for _, call := range b.calls {
+		switch {
+		case strings.HasPrefix(call.Method, "EmailSubmission/"), strings.HasPrefix(call.Method, "Identity/"):
+			usingSubmission = true
+		case strings.HasPrefix(call.Method, "Blob/") && call.Method != "Blob/copy":
+			// Blob/copy is a core method, the others come from RFC 9404
+			opts = append(opts, Using(UsingBlob))
+		}
+	}
+	opts = append(opts, PartialResults())
+	responses, err := RequestWithContext(ctx, b.client, b.calls, usingSubmission, opts...)
+	var errs MethodErrors
+	if err != nil && !errors.As(err, &errs) {
+		for _, h := range b.handles {
+			h.fail(err)
+		}
+		return err
+	}
+	for _, h := range b.handles {
+		h.resolve(responses, errs)
+	}
+	return nil
+}
+
+// Handle gives access to the result of a single call in a Batch.
+type Handle[T any] struct {
+	call      *Call
+	result    *T
+	responses []*Response
+	err       error
+	resolved  bool
+}
+
+// Call returns the call the handle was created for.
+func (h *Handle[T]) Call() *Call {
+	return h.call
+}
+
+// Reference returns a ResultReference to path in the result of h's call,
+// for use as an argument of a later call in the same Batch.
+func (h *Handle[T]) Reference(path string) *ResultReference {
+	return h.call.Reference(path)
+}
+
+// Result returns the decoded response to h's call, or the *MethodError the server returned for it.
+func (h *Handle[T]) Result() (*T, error) {
+	if !h.resolved {
+		return nil, fmt.Errorf("batch containing %s call %s has not been sent", h.call.Method, h.call.ID)
+	}
+	return h.result, h.err
+}
+
+// Responses returns every response sharing the id of h's call, in the order they were received.
+// This includes responses to implicit calls, such as the Email/set the server
+// runs after an EmailSubmission/set with onSuccessUpdateEmail.
+func (h *Handle[T]) Responses() []*Response {
+	return h.responses
+}
+
+func (h *Handle[T]) fail(err error) {
+	h.resolved = true
+	h.err = err
+}
+
+func (h *Handle[T]) resolve(responses []*Response, errs MethodErrors) {
+	h.resolved = true
+	id := h.call.ID
+	for _, resp := range responses {
+		if resp.ID == id {
+			h.responses = append(h.responses, resp)
+		}
+	}
+	for _, e := range errs {
+		if e.CallID == id {
+			h.err = e
+			return
+		}
+	}
+	for _, resp := range h.responses {
+		if resp.Method == h.call.Method {
+			var result T
+			if err := resp.Decode(&result); err != nil {
+				h.err = err
+				return
+			}
+			h.result = &result
+			return
+		}
+	}
+	h.err = fmt.Errorf("no response found for %s call %s", h.call.Method, id)
+}
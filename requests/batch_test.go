@@ -0,0 +1,89 @@
+package requests_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cwinters8/gomap/internal/jmaptest"
+	"github.com/cwinters8/gomap/requests"
+	"github.com/cwinters8/gomap/utils"
+)
+
+func TestBatch(t *testing.T) {
+	f := jmaptest.NewServer(t, func(method string, args map[string]any) (string, map[string]any) {
+		switch method {
+		case "Email/query":
+			return method, map[string]any{"queryState": "q1", "ids": []any{"M1", "M2"}}
+		case "Email/get":
+			return method, map[string]any{"state": "1", "list": []any{
+				map[string]any{"id": "M1", "subject": "hello"},
+				map[string]any{"id": "M2", "subject": "world"},
+			}}
+		}
+		return "error", map[string]any{"type": "unknownMethod"}
+	})
+	b := requests.NewBatch(f.NewClient())
+	query := requests.Add[struct {
+		QueryState string   `json:"queryState"`
+		IDs        []string `json:"ids"`
+	}](b, &requests.Call{
+		ID:        requests.NewCallID(),
+		AccountID: "xyz",
+		Method:    "Email/query",
+		Arguments: map[string]any{},
+	})
+	getCall := requests.Call{
+		ID:        requests.NewCallID(),
+		AccountID: "xyz",
+		Method:    "Email/get",
+		Arguments: map[string]any{},
+	}
+	if err := getCall.SetReference("ids", query.Reference("/ids")); err != nil {
+		t.Fatalf("failed to set reference: %s", err.Error())
+	}
+	get := requests.Add[struct {
+		List []struct {
+			ID      string `json:"id"`
+			Subject string `json:"subject"`
+		} `json:"list"`
+	}](b, &getCall)
+	unknown := requests.Add[map[string]any](b, &requests.Call{
+		ID:        requests.NewCallID(),
+		AccountID: "xyz",
+		Method:    "Foo/bar",
+		Arguments: map[string]any{},
+	})
+	if _, err := get.Result(); err == nil {
+		t.Error("wanted an error reading a handle before sending")
+	}
+	if err := b.Send(context.Background()); err != nil {
+		t.Fatalf("batch send failure: %s", err.Error())
+	}
+	if err := b.Send(context.Background()); err == nil {
+		t.Error("wanted an error sending a batch twice")
+	}
+	queryResult, err := query.Result()
+	if err != nil {
+		t.Fatalf("query handle failure: %s", err.Error())
+	}
+	getResult, err := get.Result()
+	if err != nil {
+		t.Fatalf("get handle failure: %s", err.Error())
+	}
+	_, unknownErr := unknown.Result()
+	var methodErr *requests.MethodError
+	cases := utils.Cases{
+		utils.NewCase(len(f.Requests()) != 1, "wanted 1 request; got %d", len(f.Requests())),
+		utils.NewCase(queryResult.QueryState != "q1", "wanted query state q1; got %s", queryResult.QueryState),
+		utils.NewCase(len(queryResult.IDs) != 2, "wanted 2 ids; got %d", len(queryResult.IDs)),
+		utils.NewCase(len(getResult.List) != 2, "wanted 2 emails; got %d", len(getResult.List)),
+		utils.NewCase(!errors.As(unknownErr, &methodErr), "wanted a *requests.MethodError; got %v", unknownErr),
+	}
+	if len(getResult.List) == 2 {
+		cases.Append(utils.NewCase(getResult.List[1].Subject != "world", "wanted subject world; got %s", getResult.List[1].Subject))
+	}
+	cases.Iterator(func(c *utils.Case) {
+		t.Error(c.Message)
+	})
+}
@@ -3,24 +3,51 @@ package requests
 import (
 	"encoding/json"
 	"fmt"
-
-	"github.com/google/uuid"
+	"strconv"
+	"strings"
+	"sync/atomic"
 )
 
 type Call struct {
-	ID        uuid.UUID
+	ID        string // opaque to the server, which echoes it back with every response to the call
 	AccountID string
 	Method    string
 	Arguments map[string]any
-	OnSuccess func(map[string]any) error
+	OnSuccess func(body json.RawMessage) error // called with the body of the response whose method matches Method
+	// OnResponse is called for every response sharing the call's ID, including
+	// responses to implicit calls such as the Email/set that follows an
+	// EmailSubmission/set with onSuccessUpdateEmail.
+	OnResponse func(*Response) error
 	// OnError   func(error) error
 }
 
+var callCount atomic.Uint64
+
+// NewCallID returns a short call id that is unique within the running process.
+func NewCallID() string {
+	return "c" + strconv.FormatUint(callCount.Add(1), 36)
+}
+
 func (c Call) MarshalJSON() ([]byte, error) {
 	if c.Arguments == nil {
 		return nil, fmt.Errorf("c.Arguments field must not be nil")
 	}
-	c.Arguments["accountId"] = c.AccountID
+	// some methods, such as PushSubscription/get, don't act on an account
+	if len(c.AccountID) > 0 {
+		c.Arguments["accountId"] = c.AccountID
+	}
 	slice := [3]any{c.Method, c.Arguments, c.ID}
 	return json.Marshal(slice)
 }
+
+// Idempotent reports whether sending the call more than once has the same effect as sending it once.
+// Only read-only standard methods such as Foo/get and Foo/query are considered idempotent,
+// so that retries never repeat a creation or submission.
+func (c *Call) Idempotent() bool {
+	_, name, _ := strings.Cut(c.Method, "/")
+	switch name {
+	case "get", "query", "changes", "queryChanges", "lookup":
+		return true
+	}
+	return false
+}
@@ -4,25 +4,20 @@ import (
 	"encoding/json"
 	"testing"
 
+	"github.com/cwinters8/gomap/internal/jmaptest"
 	"github.com/cwinters8/gomap/requests"
 	"github.com/cwinters8/gomap/utils"
-
-	"github.com/google/uuid"
 )
 
 func TestCallMarshal(t *testing.T) {
-	id, err := uuid.NewRandom()
-	if err != nil {
-		t.Fatalf("failed to generate new uuid: %s", err.Error())
-	}
 	c := requests.Call{
 		AccountID: "xyz",
-		ID:        id,
+		ID:        requests.NewCallID(),
 		Method:    "Mailbox/query",
 		Arguments: map[string]any{
 			"hello": "world",
 		},
-		OnSuccess: func(b map[string]any) error {
+		OnSuccess: func(body json.RawMessage) error {
 			return nil
 		},
 	}
@@ -64,14 +59,10 @@ func TestCallMarshal(t *testing.T) {
 		"wanted hello message %s; got %s",
 		c.Arguments["hello"], hello,
 	))
-	strID, ok := slice[2].(string)
+	gotID, ok := slice[2].(string)
 	if !ok {
 		t.Fatalf("failed to cast id to string. %s", utils.Describe(slice[2]))
 	}
-	gotID, err := uuid.Parse(strID)
-	if err != nil {
-		t.Fatalf("failed to parse `%s` as uuid: %s", strID, err.Error())
-	}
 	cases.Append(utils.NewCase(
 		gotID != c.ID,
 		"wanted id %s; got %s",
@@ -81,3 +72,80 @@ func TestCallMarshal(t *testing.T) {
 		t.Error(c.Message)
 	})
 }
+
+func TestCallIdempotent(t *testing.T) {
+	for method, want := range map[string]bool{
+		"Email/get":           true,
+		"Email/query":         true,
+		"Mailbox/changes":     true,
+		"Email/queryChanges":  true,
+		"Email/set":           false,
+		"EmailSubmission/set": false,
+		"Email/import":        false,
+	} {
+		c := requests.Call{Method: method}
+		if got := c.Idempotent(); got != want {
+			t.Errorf("%s: wanted idempotent %t; got %t", method, want, got)
+		}
+	}
+}
+
+func TestImplicitResponses(t *testing.T) {
+	call := requests.Call{
+		ID:        "submit-1",
+		AccountID: "xyz",
+		Method:    "EmailSubmission/set",
+		Arguments: map[string]any{},
+	}
+	f := jmaptest.NewServer(t, func(method string, args map[string]any) (string, map[string]any) {
+		return method, map[string]any{"created": map[string]any{"k1": map[string]any{"id": "S1"}}}
+	})
+	f.Implicit = map[string]jmaptest.Handler{
+		"EmailSubmission/set": func(method string, args map[string]any) (string, map[string]any) {
+			return "Email/set", map[string]any{"updated": map[string]any{"M1": nil}}
+		},
+	}
+	c := f.NewClient()
+	successes := 0
+	methods := []string{}
+	call.OnSuccess = func(json.RawMessage) error {
+		successes++
+		return nil
+	}
+	call.OnResponse = func(r *requests.Response) error {
+		methods = append(methods, r.Method)
+		return nil
+	}
+	responses, err := requests.Request(c, []*requests.Call{&call}, true)
+	if err != nil {
+		t.Fatalf("request failure: %s", err.Error())
+	}
+	cases := utils.Cases{
+		utils.NewCase(len(responses) != 2, "wanted 2 responses; got %d", len(responses)),
+		utils.NewCase(successes != 1, "wanted OnSuccess to be called once; got %d", successes),
+		utils.NewCase(len(methods) != 2, "wanted OnResponse to be called twice; got %d", len(methods)),
+	}
+	if len(methods) == 2 {
+		cases.Append(utils.NewCase(methods[1] != "Email/set", "wanted implicit Email/set response; got %s", methods[1]))
+	}
+	for _, resp := range responses {
+		cases.Append(utils.NewCase(resp.ID != call.ID, "wanted response id %s; got %s", call.ID, resp.ID))
+	}
+	cases.Iterator(func(c *utils.Case) {
+		t.Error(c.Message)
+	})
+}
+
+func TestNewCallID(t *testing.T) {
+	seen := map[string]bool{}
+	for i := 0; i < 1000; i++ {
+		id := requests.NewCallID()
+		if seen[id] {
+			t.Fatalf("call id %s generated twice", id)
+		}
+		if len(id) > 8 {
+			t.Errorf("wanted a short call id; got %s", id)
+		}
+		seen[id] = true
+	}
+}
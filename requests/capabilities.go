@@ -4,6 +4,7 @@ const (
 	UsingCore       Capability = "urn:ietf:params:jmap:core"
 	UsingMail       Capability = "urn:ietf:params:jmap:mail"
 	UsingSubmission Capability = "urn:ietf:params:jmap:submission"
+	UsingBlob       Capability = "urn:ietf:params:jmap:blob"
 )
 
 type Capability string
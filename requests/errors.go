@@ -0,0 +1,139 @@
+package requests
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ErrorType is the type of a JMAP method-level error (RFC 8620 section 3.6.2).
+type ErrorType string
+
+// general method errors that any method may return
+const (
+	ErrorServerUnavailable           ErrorType = "serverUnavailable"
+	ErrorServerFail                  ErrorType = "serverFail"
+	ErrorServerPartialFail           ErrorType = "serverPartialFail"
+	ErrorUnknownMethod               ErrorType = "unknownMethod"
+	ErrorInvalidArguments            ErrorType = "invalidArguments"
+	ErrorInvalidResultReference      ErrorType = "invalidResultReference"
+	ErrorForbidden                   ErrorType = "forbidden"
+	ErrorAccountNotFound             ErrorType = "accountNotFound"
+	ErrorAccountNotSupportedByMethod ErrorType = "accountNotSupportedByMethod"
+	ErrorAccountReadOnly             ErrorType = "accountReadOnly"
+)
+
+// errors returned by specific standard methods
+const (
+	ErrorRequestTooLarge                 ErrorType = "requestTooLarge"
+	ErrorStateMismatch                   ErrorType = "stateMismatch"
+	ErrorCannotCalculateChanges          ErrorType = "cannotCalculateChanges"
+	ErrorAnchorNotFound                  ErrorType = "anchorNotFound"
+	ErrorUnsupportedSort                 ErrorType = "unsupportedSort"
+	ErrorUnsupportedFilter               ErrorType = "unsupportedFilter"
+	ErrorTooManyChanges                  ErrorType = "tooManyChanges"
+	ErrorFromAccountNotFound             ErrorType = "fromAccountNotFound"
+	ErrorFromAccountNotSupportedByMethod ErrorType = "fromAccountNotSupportedByMethod"
+)
+
+// MethodError is an "error" response to a single method call.
+type MethodError struct {
+	CallID      string    `json:"-"`
+	Method      string    `json:"-"` // method name of the call that failed, if it was part of the request
+	Type        ErrorType `json:"type"`
+	Description string    `json:"description"`
+	Arguments   []string  `json:"arguments"` // invalid argument names, when Type is ErrorInvalidArguments
+}
+
+func (e *MethodError) Error() string {
+	msg := fmt.Sprintf("method error `%s`", e.Type)
+	if len(e.Method) > 0 {
+		msg = fmt.Sprintf("%s for %s call %s", msg, e.Method, e.CallID)
+	} else {
+		msg = fmt.Sprintf("%s for call %s", msg, e.CallID)
+	}
+	if len(e.Description) > 0 {
+		msg += ": " + e.Description
+	}
+	return msg
+}
+
+// MethodErrors holds every method error returned from a single request.
+// Use errors.As to retrieve an individual *MethodError.
+type MethodErrors []*MethodError
+
+func (errs MethodErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Error()
+	}
+	return fmt.Sprintf("found %d method errors: %s", len(errs), strings.Join(msgs, "; "))
+}
+
+func (errs MethodErrors) Unwrap() []error {
+	unwrapped := make([]error, len(errs))
+	for i, e := range errs {
+		unwrapped[i] = e
+	}
+	return unwrapped
+}
+
+func (errs MethodErrors) has(t ErrorType) bool {
+	for _, e := range errs {
+		if e.Type == t {
+			return true
+		}
+	}
+	return false
+}
+
+// SetErrorType is the type of an error creating, updating or destroying a single object
+// in Foo/set (RFC 8620 section 5.3).
+type SetErrorType string
+
+const (
+	SetErrorForbidden         SetErrorType = "forbidden"
+	SetErrorOverQuota         SetErrorType = "overQuota"
+	SetErrorTooLarge          SetErrorType = "tooLarge"
+	SetErrorRateLimit         SetErrorType = "rateLimit"
+	SetErrorNotFound          SetErrorType = "notFound"
+	SetErrorInvalidPatch      SetErrorType = "invalidPatch"
+	SetErrorWillDestroy       SetErrorType = "willDestroy"
+	SetErrorInvalidProperties SetErrorType = "invalidProperties"
+	SetErrorSingleton         SetErrorType = "singleton"
+	SetErrorAlreadyExists     SetErrorType = "alreadyExists"
+)
+
+// SetError explains why a single object in Foo/set was not created, updated or destroyed.
+type SetError struct {
+	Type        SetErrorType `json:"type"`
+	Description string       `json:"description"`
+	Properties  []string     `json:"properties"` // invalid properties, when Type is SetErrorInvalidProperties
+	ExistingID  string       `json:"existingId"` // the existing object, when Type is SetErrorAlreadyExists
+}
+
+func (e *SetError) Error() string {
+	msg := fmt.Sprintf("set error `%s`", e.Type)
+	if len(e.Properties) > 0 {
+		msg += fmt.Sprintf(" for properties %v", e.Properties)
+	}
+	if len(e.Description) > 0 {
+		msg += ": " + e.Description
+	}
+	return msg
+}
+
+// ObjectError is the SetError of a single object in a Foo/set response.
+// Use errors.As to retrieve the *SetError.
+type ObjectError struct {
+	ID  string // the object's id, or its creation id when it wasn't created
+	Op  string // "create", "update" or "destroy"
+	Err *SetError
+}
+
+func (e *ObjectError) Error() string {
+	return fmt.Sprintf("failed to %s %s: %s", e.Op, e.ID, e.Err.Error())
+}
+
+func (e *ObjectError) Unwrap() error {
+	return e.Err
+}
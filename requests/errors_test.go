@@ -0,0 +1,111 @@
+package requests_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cwinters8/gomap/client"
+	"github.com/cwinters8/gomap/internal/jmaptest"
+	"github.com/cwinters8/gomap/requests"
+	"github.com/cwinters8/gomap/utils"
+)
+
+func TestMethodErrors(t *testing.T) {
+	getCall := requests.Call{
+		ID:        requests.NewCallID(),
+		AccountID: "xyz",
+		Method:    "Mailbox/get",
+		Arguments: map[string]any{},
+	}
+	setCall := requests.Call{
+		ID:        requests.NewCallID(),
+		AccountID: "xyz",
+		Method:    "Email/set",
+		Arguments: map[string]any{"ifInState": "abc"},
+	}
+	c := jmaptest.NewServer(t, func(method string, args map[string]any) (string, map[string]any) {
+		if method == "Email/set" {
+			return "error", map[string]any{"type": "stateMismatch", "description": "state is stale"}
+		}
+		return method, map[string]any{"accountId": "xyz", "state": "1", "list": []any{}, "notFound": []any{}}
+	}).NewClient()
+
+	t.Run("default", func(t *testing.T) {
+		responses, err := requests.Request(c, []*requests.Call{&getCall, &setCall}, false)
+		var methodErr *requests.MethodError
+		if !errors.As(err, &methodErr) {
+			t.Fatalf("wanted a *requests.MethodError; got %v", err)
+		}
+		cases := utils.Cases{
+			utils.NewCase(responses != nil, "wanted nil responses; got %d", len(responses)),
+			utils.NewCase(methodErr.Type != requests.ErrorStateMismatch, "wanted error type %s; got %s", requests.ErrorStateMismatch, methodErr.Type),
+			utils.NewCase(methodErr.CallID != setCall.ID, "wanted call id %s; got %s", setCall.ID, methodErr.CallID),
+			utils.NewCase(methodErr.Method != setCall.Method, "wanted method %s; got %s", setCall.Method, methodErr.Method),
+			utils.NewCase(methodErr.Description != "state is stale", "wanted description `state is stale`; got `%s`", methodErr.Description),
+		}
+		cases.Iterator(func(c *utils.Case) {
+			t.Error(c.Message)
+		})
+	})
+
+	t.Run("partial results", func(t *testing.T) {
+		responses, err := requests.Request(c, []*requests.Call{&getCall, &setCall}, false, requests.PartialResults())
+		var errs requests.MethodErrors
+		if !errors.As(err, &errs) {
+			t.Fatalf("wanted requests.MethodErrors; got %v", err)
+		}
+		if len(responses) != 1 {
+			t.Fatalf("wanted 1 response; got %d", len(responses))
+		}
+		cases := utils.Cases{
+			utils.NewCase(len(errs) != 1, "wanted 1 method error; got %d", len(errs)),
+			utils.NewCase(responses[0].ID != getCall.ID, "wanted response id %s; got %s", getCall.ID, responses[0].ID),
+			utils.NewCase(responses[0].Method != getCall.Method, "wanted response method %s; got %s", getCall.Method, responses[0].Method),
+		}
+		cases.Iterator(func(c *utils.Case) {
+			t.Error(c.Message)
+		})
+	})
+}
+
+func TestServerUnavailableRetry(t *testing.T) {
+	call := requests.Call{
+		ID:        requests.NewCallID(),
+		AccountID: "xyz",
+		Method:    "Email/query",
+		Arguments: map[string]any{},
+	}
+	var hits atomic.Int32
+	c := jmaptest.NewServer(t, func(method string, args map[string]any) (string, map[string]any) {
+		if hits.Add(1) == 1 {
+			return "error", map[string]any{"type": "serverUnavailable"}
+		}
+		return method, map[string]any{"ids": []any{"M1"}}
+	}).NewClient()
+	c.Retry = &client.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}
+	responses, err := requests.Request(c, []*requests.Call{&call}, false)
+	if err != nil {
+		t.Fatalf("wanted request to succeed after retrying; got %s", err.Error())
+	}
+	cases := utils.Cases{
+		utils.NewCase(len(responses) != 1, "wanted 1 response; got %d", len(responses)),
+		utils.NewCase(hits.Load() != 2, "wanted 2 attempts; got %d", hits.Load()),
+	}
+	cases.Iterator(func(c *utils.Case) {
+		t.Error(c.Message)
+	})
+
+	// a non-idempotent call must not be repeated
+	hits.Store(0)
+	call.Method = "EmailSubmission/set"
+	_, err = requests.Request(c, []*requests.Call{&call}, true)
+	var methodErr *requests.MethodError
+	if !errors.As(err, &methodErr) || methodErr.Type != requests.ErrorServerUnavailable {
+		t.Errorf("wanted serverUnavailable method error; got %v", err)
+	}
+	if got := hits.Load(); got != 1 {
+		t.Errorf("wanted EmailSubmission/set to be attempted once; got %d", got)
+	}
+}
@@ -0,0 +1,567 @@
+package requests
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cwinters8/gomap/client"
+)
+
+// limits are the core capability limits that affect how calls are sent.
+// A zero value means the server did not advertise the limit.
+type limits struct {
+	maxCalls    int
+	maxSize     int
+	maxGet      int
+	maxSet      int
+	usingLength int
+	// createdLength is the size of the createdIds sent with every request
+	createdLength int
+	// ids are call ids in use outside the calls being planned, which chunks can't take
+	ids map[string]bool
+}
+
+func newLimits(sess *client.Session, using []Capability) limits {
+	l := limits{}
+	if b, err := json.Marshal(using); err == nil {
+		l.usingLength = len(b)
+	}
+	if sess == nil || sess.Capabilities == nil || sess.Capabilities.Core == nil {
+		return l
+	}
+	core := sess.Capabilities.Core
+	l.maxCalls = core.MaxCallsInRequest
+	l.maxSize = core.MaxSizeRequest
+	l.maxGet = core.MaxObjectsInGet
+	l.maxSet = core.MaxObjectsInSet
+	return l
+}
+
+// plan splits calls so that every request sent stays within the server's limits.
+//
+// Foo/get calls with more ids than maxObjectsInGet and Foo/set calls with more
+// objects than maxObjectsInSet are split into several chunks, each with its own call id,
+// and the resulting calls are grouped into as many requests as maxCallsInRequest and
+// maxSizeRequest require. The returned chunks map each chunk's call id to the id of the
+// call it came from, so mergeResponses can put the responses back together.
+//
+// Calls linked by result references are always sent in the same request and are never split.
+// Calls keep their order: any calls between linked ones go in the same request too, unless
+// they don't all fit, in which case the linked calls are sent after the calls in between.
+// That is only done when all of those calls are idempotent; a call that changes something
+// is never moved or moved past, and an error is returned instead.
+func (l limits) plan(calls []*Call) (batches [][]*Call, chunks map[string]string, err error) {
+	referenced := map[string]bool{}
+	// ids already in use, so a chunk never takes the id of another call
+	taken := map[string]bool{}
+	for id := range l.ids {
+		taken[id] = true
+	}
+	for _, c := range calls {
+		taken[c.ID] = true
+	}
+	for _, c := range calls {
+		for _, id := range c.references() {
+			referenced[id] = true
+		}
+	}
+	// units are groups of calls that must be sent together
+	units := [][]*Call{}
+	unitOf := map[string]int{}
+	for _, c := range calls {
+		refs := c.references()
+		if len(refs) == 0 && !referenced[c.ID] {
+			parts, err := l.split(c)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to split %s call %s: %w", c.Method, c.ID, err)
+			}
+			for i, part := range parts {
+				if len(parts) > 1 {
+					if chunks == nil {
+						chunks = map[string]string{}
+					}
+					part.ID = chunkID(c.ID, i, taken)
+					chunks[part.ID] = c.ID
+				}
+				units = append(units, []*Call{part})
+			}
+			unitOf[c.ID] = len(units) - 1
+			continue
+		}
+		first := len(units)
+		refUnits := map[int]bool{}
+		for _, id := range refs {
+			if u, ok := unitOf[id]; ok {
+				refUnits[u] = true
+				if u < first {
+					first = u
+				}
+			}
+		}
+		if first == len(units) {
+			units = append(units, []*Call{c})
+			unitOf[c.ID] = first
+			continue
+		}
+		span := []*Call{}
+		for _, unit := range units[first:] {
+			span = append(span, unit...)
+		}
+		span = append(span, c)
+		fits, err := l.fits(span)
+		if err != nil {
+			return nil, nil, err
+		}
+		group := span
+		if fits {
+			// send the calls in between along with the group, so nothing is reordered
+			for u := first; u < len(units); u++ {
+				units[u] = nil
+			}
+			units[first] = group
+		} else {
+			// the calls in between can't be sent with the group, so the referenced calls
+			// move after them, which is only safe when neither side changes anything
+			group = []*Call{}
+			for u := first; u < len(units); u++ {
+				for _, other := range units[u] {
+					if !other.Idempotent() {
+						return nil, nil, fmt.Errorf("%s call %s and the calls it references can't be kept together within maxCallsInRequest %d and maxSizeRequest %d without reordering %s call %s", c.Method, c.ID, l.maxCalls, l.maxSize, other.Method, other.ID)
+					}
+				}
+			}
+			for u := first; u < len(units); u++ {
+				if refUnits[u] {
+					group = append(group, units[u]...)
+					units[u] = nil
+				}
+			}
+			group = append(group, c)
+			units = append(units, group)
+			first = len(units) - 1
+		}
+		for _, moved := range group {
+			unitOf[moved.ID] = first
+		}
+	}
+	batch := []*Call{}
+	size := l.overhead()
+	for _, unit := range units {
+		if unit == nil {
+			continue
+		}
+		unitSize := 0
+		for _, c := range unit {
+			callSize, err := marshaledSize(c)
+			if err != nil {
+				return nil, nil, err
+			}
+			unitSize += callSize + 1
+		}
+		full := l.maxCalls > 0 && len(batch)+len(unit) > l.maxCalls
+		tooBig := l.maxSize > 0 && size+unitSize > l.maxSize
+		if len(batch) > 0 && (full || tooBig) {
+			batches = append(batches, batch)
+			batch = []*Call{}
+			size = l.overhead()
+		}
+		batch = append(batch, unit...)
+		size += unitSize
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return batches, chunks, nil
+}
+
+// replan plans the calls from batches[i] onward again when createdIds has grown past the size
+// l was planned with and batches[i] no longer fits, adding any new chunks to chunks.
+// calls are all the calls of the request, whose ids new chunks must not take.
+func replan(l *limits, batches [][]*Call, i int, chunks map[string]string, calls []*Call, created map[string]string) ([][]*Call, map[string]string, error) {
+	length, err := createdLength(created)
+	if err != nil {
+		return nil, nil, err
+	}
+	if length <= l.createdLength {
+		return batches, chunks, nil
+	}
+	l.createdLength = length
+	fits, err := l.fits(batches[i])
+	if err != nil || fits {
+		return batches, chunks, err
+	}
+	rest := []*Call{}
+	for _, batch := range batches[i:] {
+		rest = append(rest, batch...)
+	}
+	l.ids = map[string]bool{}
+	for _, c := range calls {
+		l.ids[c.ID] = true
+	}
+	for id := range chunks {
+		l.ids[id] = true
+	}
+	more, moreChunks, err := l.plan(rest)
+	if err != nil {
+		return nil, nil, err
+	}
+	for id, from := range moreChunks {
+		// a chunk of a chunk belongs to the original call
+		if original, ok := chunks[from]; ok {
+			from = original
+		}
+		if chunks == nil {
+			chunks = map[string]string{}
+		}
+		chunks[id] = from
+	}
+	return append(batches[:i:i], more...), chunks, nil
+}
+
+// chunkID returns an id for chunk i of the call with id that isn't taken yet, and takes it.
+func chunkID(id string, i int, taken map[string]bool) string {
+	base := fmt.Sprintf("%s.%d", id, i)
+	chunk := base
+	for n := 1; taken[chunk]; n++ {
+		chunk = fmt.Sprintf("%s~%d", base, n)
+	}
+	taken[chunk] = true
+	return chunk
+}
+
+// overhead is the size of a request without any calls.
+func (l limits) overhead() int {
+	return len(`{"using":,"methodCalls":[]}`) + l.usingLength + l.createdLength
+}
+
+// createdLength returns the size that sending created as createdIds adds to a request.
+func createdLength(created map[string]string) (int, error) {
+	if created == nil {
+		return 0, nil
+	}
+	b, err := json.Marshal(created)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal createdIds: %w", err)
+	}
+	return len(`,"createdIds":`) + len(b), nil
+}
+
+// fits reports whether calls can be sent in a single request.
+func (l limits) fits(calls []*Call) (bool, error) {
+	if l.maxCalls > 0 && len(calls) > l.maxCalls {
+		return false, nil
+	}
+	if l.maxSize < 1 {
+		return true, nil
+	}
+	size := l.overhead()
+	for _, c := range calls {
+		callSize, err := marshaledSize(c)
+		if err != nil {
+			return false, err
+		}
+		size += callSize + 1
+	}
+	return size <= l.maxSize, nil
+}
+
+// split breaks c into calls small enough for the server's object and size limits.
+// Calls that can't be split are returned as they are.
+func (l limits) split(c *Call) ([]*Call, error) {
+	_, name, _ := strings.Cut(c.Method, "/")
+	var (
+		chunks []*Call
+		err    error
+	)
+	switch name {
+	case "get":
+		chunks, err = splitGet(c, l.maxGet)
+	case "set":
+		chunks, err = splitSet(c, l.maxSet)
+	default:
+		return []*Call{c}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if l.maxSize < 1 {
+		return chunks, nil
+	}
+	// halve any chunk that is still too large to send on its own
+	sized := []*Call{}
+	for len(chunks) > 0 {
+		chunk := chunks[0]
+		chunks = chunks[1:]
+		size, err := marshaledSize(chunk)
+		if err != nil {
+			return nil, err
+		}
+		n := objectCount(chunk)
+		if l.overhead()+size <= l.maxSize || n < 2 {
+			sized = append(sized, chunk)
+			continue
+		}
+		var halves []*Call
+		if name == "get" {
+			halves, err = splitGet(chunk, (n+1)/2)
+		} else {
+			halves, err = splitSet(chunk, (n+1)/2)
+		}
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(halves, chunks...)
+	}
+	return sized, nil
+}
+
+func splitGet(c *Call, max int) ([]*Call, error) {
+	raw, ok := c.Arguments["ids"]
+	if !ok || raw == nil || max < 1 {
+		return []*Call{c}, nil
+	}
+	var ids []json.RawMessage
+	if err := remarshal(raw, &ids); err != nil {
+		// ids is a result reference or otherwise can't be split
+		return []*Call{c}, nil
+	}
+	if len(ids) <= max {
+		return []*Call{c}, nil
+	}
+	chunks := []*Call{}
+	for start := 0; start < len(ids); start += max {
+		end := start + max
+		if end > len(ids) {
+			end = len(ids)
+		}
+		chunk := c.chunk()
+		chunk.Arguments["ids"] = ids[start:end]
+		chunks = append(chunks, chunk)
+	}
+	return chunks, nil
+}
+
+func splitSet(c *Call, max int) ([]*Call, error) {
+	if max < 1 {
+		return []*Call{c}, nil
+	}
+	create := map[string]json.RawMessage{}
+	update := map[string]json.RawMessage{}
+	destroy := []json.RawMessage{}
+	for key, dst := range map[string]any{"create": &create, "update": &update, "destroy": &destroy} {
+		if v, ok := c.Arguments[key]; ok && v != nil {
+			if err := remarshal(v, dst); err != nil {
+				return nil, fmt.Errorf("failed to read `%s` argument: %w", key, err)
+			}
+		}
+	}
+	if len(create)+len(update)+len(destroy) <= max {
+		return []*Call{c}, nil
+	}
+	if state, ok := c.Arguments["ifInState"]; ok && state != nil && state != "" {
+		// later chunks can't know the state the earlier ones leave behind,
+		// so splitting would give up the caller's protection against concurrent changes
+		return nil, fmt.Errorf("%d objects with ifInState can't be split into sets of %d", len(create)+len(update)+len(destroy), max)
+	}
+	chunks := []*Call{}
+	var chunk *Call
+	count := 0
+	next := func() *Call {
+		if chunk == nil || count >= max {
+			chunk = c.chunk()
+			delete(chunk.Arguments, "create")
+			delete(chunk.Arguments, "update")
+			delete(chunk.Arguments, "destroy")
+			chunks = append(chunks, chunk)
+			count = 0
+		}
+		count++
+		return chunk
+	}
+	for _, key := range sortedKeys(create) {
+		ch := next()
+		m, _ := ch.Arguments["create"].(map[string]json.RawMessage)
+		if m == nil {
+			m = map[string]json.RawMessage{}
+			ch.Arguments["create"] = m
+		}
+		m[key] = create[key]
+	}
+	for _, key := range sortedKeys(update) {
+		ch := next()
+		m, _ := ch.Arguments["update"].(map[string]json.RawMessage)
+		if m == nil {
+			m = map[string]json.RawMessage{}
+			ch.Arguments["update"] = m
+		}
+		m[key] = update[key]
+	}
+	for _, id := range destroy {
+		ch := next()
+		ids, _ := ch.Arguments["destroy"].([]json.RawMessage)
+		ch.Arguments["destroy"] = append(ids, id)
+	}
+	return chunks, nil
+}
+
+// chunk copies c with its own arguments map, so a part of the call can be sent separately.
+func (c *Call) chunk() *Call {
+	args := make(map[string]any, len(c.Arguments))
+	for k, v := range c.Arguments {
+		args[k] = v
+	}
+	return &Call{
+		ID:        c.ID,
+		AccountID: c.AccountID,
+		Method:    c.Method,
+		Arguments: args,
+	}
+}
+
+// objectCount returns the number of ids or objects in a Foo/get or Foo/set call.
+func objectCount(c *Call) int {
+	n := 0
+	for _, key := range []string{"ids", "destroy"} {
+		var list []json.RawMessage
+		if err := remarshal(c.Arguments[key], &list); err == nil {
+			n += len(list)
+		}
+	}
+	for _, key := range []string{"create", "update"} {
+		var m map[string]json.RawMessage
+		if err := remarshal(c.Arguments[key], &m); err == nil {
+			n += len(m)
+		}
+	}
+	return n
+}
+
+func marshaledSize(c *Call) (int, error) {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal %s call %s: %w", c.Method, c.ID, err)
+	}
+	return len(b), nil
+}
+
+// remarshal converts v into dst by way of json.
+func remarshal(v any, dst any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, dst)
+}
+
+func sortedKeys(m map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// mergeResponses combines responses to the chunks of a split call into a single response
+// with the original call id, keeping the position of the first chunk's response.
+// chunks maps the call id of every chunk to that of the original call, as returned by plan.
+//
+// Lists such as list, notFound and destroyed are concatenated, maps such as created and
+// notUpdated are combined, oldState is taken from the first chunk and any other value
+// from the last chunk. Responses that weren't split are passed through without being decoded.
+func mergeResponses(responses []*Response, chunks map[string]string) ([]*Response, error) {
+	merged := []*Response{}
+	seen := map[string]*Response{}
+	bodies := map[*Response]map[string]json.RawMessage{}
+	for _, r := range responses {
+		id, ok := chunks[r.ID]
+		if !ok {
+			merged = append(merged, r)
+			continue
+		}
+		r.ID = id
+		key := r.ID + " " + r.Method
+		first, ok := seen[key]
+		if !ok {
+			seen[key] = r
+			merged = append(merged, r)
+			continue
+		}
+		body, ok := bodies[first]
+		if !ok {
+			if err := json.Unmarshal(first.Body, &body); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal %s response: %w", first.Method, err)
+			}
+			bodies[first] = body
+		}
+		var next map[string]json.RawMessage
+		if err := json.Unmarshal(r.Body, &next); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal %s response: %w", r.Method, err)
+		}
+		for k, v := range next {
+			prev, exists := body[k]
+			if !exists || isNull(prev) {
+				body[k] = v
+				continue
+			}
+			if isNull(v) {
+				continue
+			}
+			combined, ok, err := combine(prev, v)
+			if err != nil {
+				return nil, fmt.Errorf("failed to merge %s of %s responses: %w", k, r.Method, err)
+			}
+			switch {
+			case ok:
+				body[k] = combined
+			case k != "oldState":
+				body[k] = v
+			}
+		}
+	}
+	for r, body := range bodies {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal merged %s response: %w", r.Method, err)
+		}
+		r.Body = b
+	}
+	return merged, nil
+}
+
+// combine concatenates two JSON arrays or merges two JSON objects,
+// reporting false when a and b aren't both arrays or both objects.
+func combine(a, b json.RawMessage) (json.RawMessage, bool, error) {
+	switch {
+	case a[0] == '[' && b[0] == '[':
+		var left, right []json.RawMessage
+		if err := json.Unmarshal(a, &left); err != nil {
+			return nil, false, err
+		}
+		if err := json.Unmarshal(b, &right); err != nil {
+			return nil, false, err
+		}
+		out, err := json.Marshal(append(left, right...))
+		return out, true, err
+	case a[0] == '{' && b[0] == '{':
+		var left, right map[string]json.RawMessage
+		if err := json.Unmarshal(a, &left); err != nil {
+			return nil, false, err
+		}
+		if err := json.Unmarshal(b, &right); err != nil {
+			return nil, false, err
+		}
+		for k, v := range right {
+			left[k] = v
+		}
+		out, err := json.Marshal(left)
+		return out, true, err
+	}
+	return nil, false, nil
+}
+
+func isNull(v json.RawMessage) bool {
+	return len(v) < 1 || string(v) == "null"
+}
@@ -0,0 +1,232 @@
+package requests_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/cwinters8/gomap/client"
+	"github.com/cwinters8/gomap/internal/jmaptest"
+	"github.com/cwinters8/gomap/requests"
+	"github.com/cwinters8/gomap/utils"
+	"github.com/google/uuid"
+)
+
+// withLimits returns a client for f whose session has the core capabilities core.
+func withLimits(f *jmaptest.Server, core *client.CoreCapabilities) *client.Client {
+	c := f.NewClient()
+	c.Session.Capabilities = &client.Capabilities{Core: core}
+	return c
+}
+
+// echo answers Foo/get with an object for each id and Foo/set by creating each object.
+func echo(method string, args map[string]any) (string, map[string]any) {
+	switch method {
+	case "Email/get":
+		list := []any{}
+		for _, id := range args["ids"].([]any) {
+			list = append(list, map[string]any{"id": id})
+		}
+		return method, map[string]any{"state": "1", "list": list, "notFound": []any{}}
+	case "Email/set":
+		created := map[string]any{}
+		for k := range args["create"].(map[string]any) {
+			created[k] = map[string]any{"id": "M" + k}
+		}
+		return method, map[string]any{"oldState": "1", "newState": "2", "created": created}
+	}
+	return "error", map[string]any{"type": "unknownMethod"}
+}
+
+func TestLimits(t *testing.T) {
+	f := jmaptest.NewServer(t, echo)
+	c := withLimits(f, &client.CoreCapabilities{
+		MaxCallsInRequest: 2,
+		MaxObjectsInGet:   2,
+		MaxObjectsInSet:   3,
+	})
+
+	t.Run("get", func(t *testing.T) {
+		f.Reset()
+		ids := []string{"a", "b", "c", "d", "e"}
+		var got []any
+		call := requests.Call{
+			ID:        requests.NewCallID(),
+			AccountID: "xyz",
+			Method:    "Email/get",
+			Arguments: map[string]any{"ids": ids},
+			OnSuccess: func(body json.RawMessage) error {
+				var resp struct {
+					List []any `json:"list"`
+				}
+				if err := json.Unmarshal(body, &resp); err != nil {
+					return err
+				}
+				got = resp.List
+				return nil
+			},
+		}
+		responses, err := requests.Request(c, []*requests.Call{&call}, false)
+		if err != nil {
+			t.Fatalf("request failure: %s", err.Error())
+		}
+		cases := utils.Cases{
+			utils.NewCase(len(f.Requests()) != 2, "wanted 3 chunks sent in 2 requests; got %d requests", len(f.Requests())),
+			utils.NewCase(len(responses) != 1, "wanted chunks merged into 1 response; got %d", len(responses)),
+			utils.NewCase(len(got) != len(ids), "wanted %d emails passed to OnSuccess; got %d", len(ids), len(got)),
+		}
+		for _, req := range f.Requests() {
+			for _, raw := range req["methodCalls"].([]any) {
+				args := raw.([]any)[1].(map[string]any)
+				n := len(args["ids"].([]any))
+				cases.Append(utils.NewCase(n > 2, "wanted at most 2 ids per Email/get; got %d", n))
+			}
+		}
+		cases.Iterator(func(c *utils.Case) {
+			t.Error(c.Message)
+		})
+	})
+
+	t.Run("set", func(t *testing.T) {
+		f.Reset()
+		create := map[string]any{}
+		for i := 0; i < 7; i++ {
+			create[fmt.Sprintf("k%d", i)] = map[string]any{"subject": i}
+		}
+		call := requests.Call{
+			ID:        requests.NewCallID(),
+			AccountID: "xyz",
+			Method:    "Email/set",
+			Arguments: map[string]any{"create": create},
+		}
+		responses, err := requests.Request(c, []*requests.Call{&call}, false)
+		if err != nil {
+			t.Fatalf("request failure: %s", err.Error())
+		}
+		if len(responses) != 1 {
+			t.Fatalf("wanted 1 merged response; got %d", len(responses))
+		}
+		var body struct {
+			OldState string         `json:"oldState"`
+			Created  map[string]any `json:"created"`
+		}
+		if err := responses[0].Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		keys := []string{}
+		for k := range body.Created {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		cases := utils.Cases{
+			utils.NewCase(len(f.Requests()) != 2, "wanted 3 chunks sent in 2 requests; got %d requests", len(f.Requests())),
+			utils.NewCase(len(keys) != len(create), "wanted %d created emails; got %v", len(create), keys),
+			utils.NewCase(body.OldState != "1", "wanted oldState from the first chunk; got %s", body.OldState),
+			utils.NewCase(responses[0].ID != call.ID, "wanted the merged response to have call id %s; got %s", call.ID, responses[0].ID),
+		}
+		cases.Iterator(func(c *utils.Case) {
+			t.Error(c.Message)
+		})
+	})
+
+	t.Run("set with state", func(t *testing.T) {
+		f.Reset()
+		create := map[string]any{}
+		for i := 0; i < 4; i++ {
+			create[fmt.Sprintf("k%d", i)] = map[string]any{"subject": i}
+		}
+		call := requests.Call{
+			ID:        requests.NewCallID(),
+			AccountID: "xyz",
+			Method:    "Email/set",
+			Arguments: map[string]any{"create": create, "ifInState": "1"},
+		}
+		if _, err := requests.Request(c, []*requests.Call{&call}, false); err == nil {
+			t.Error("wanted an error for a set with ifInState that needs splitting")
+		}
+		if len(f.Requests()) != 0 {
+			t.Errorf("wanted nothing sent; got %d requests", len(f.Requests()))
+		}
+	})
+
+	t.Run("unsplit calls", func(t *testing.T) {
+		f.Reset()
+		calls := []*requests.Call{
+			{ID: "same", AccountID: "xyz", Method: "Email/get", Arguments: map[string]any{"ids": []string{"a"}}},
+			{ID: "same", AccountID: "xyz", Method: "Email/get", Arguments: map[string]any{"ids": []string{"b"}}},
+		}
+		responses, err := requests.Request(c, calls, false)
+		if err != nil {
+			t.Fatalf("request failure: %s", err.Error())
+		}
+		if len(responses) != 2 {
+			t.Errorf("wanted responses to calls that weren't split to be left apart; got %d", len(responses))
+		}
+	})
+
+	t.Run("chunk ids", func(t *testing.T) {
+		f.Reset()
+		calls := []*requests.Call{
+			{ID: "c1", AccountID: "xyz", Method: "Email/get", Arguments: map[string]any{"ids": []string{"a", "b", "c"}}},
+			{ID: "c1.0", AccountID: "xyz", Method: "Email/get", Arguments: map[string]any{"ids": []string{"d"}}},
+		}
+		responses, err := requests.Request(c, calls, false)
+		if err != nil {
+			t.Fatalf("request failure: %s", err.Error())
+		}
+		if len(responses) != 2 {
+			t.Fatalf("wanted a response to the split call and one to the call named like its chunk; got %d", len(responses))
+		}
+		lists := map[string]int{}
+		for _, r := range responses {
+			var body struct {
+				List []any `json:"list"`
+			}
+			if err := r.Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			lists[r.ID] = len(body.List)
+		}
+		if lists["c1"] != 3 || lists["c1.0"] != 1 {
+			t.Errorf("wanted 3 emails for c1 and 1 for c1.0; got %v", lists)
+		}
+	})
+
+	t.Run("request size", func(t *testing.T) {
+		f.Reset()
+		c := withLimits(f, &client.CoreCapabilities{MaxSizeRequest: 400})
+		ids := []string{}
+		for i := 0; i < 40; i++ {
+			ids = append(ids, uuid.NewString())
+		}
+		call := requests.Call{
+			ID:        requests.NewCallID(),
+			AccountID: "xyz",
+			Method:    "Email/get",
+			Arguments: map[string]any{"ids": ids},
+		}
+		responses, err := requests.Request(c, []*requests.Call{&call}, false)
+		if err != nil {
+			t.Fatalf("request failure: %s", err.Error())
+		}
+		var body struct {
+			List []any `json:"list"`
+		}
+		if err := responses[0].Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if len(body.List) != len(ids) {
+			t.Errorf("wanted %d emails; got %d", len(ids), len(body.List))
+		}
+		if len(f.Requests()) < 2 {
+			t.Errorf("wanted request to be split; got %d requests", len(f.Requests()))
+		}
+		for _, req := range f.Requests() {
+			b, _ := json.Marshal(req)
+			if len(b) > 400 {
+				t.Errorf("wanted requests of at most 400 bytes; got %d", len(b))
+			}
+		}
+	})
+}
@@ -0,0 +1,344 @@
+package requests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/cwinters8/gomap/client"
+)
+
+// Type describes a JMAP data type so the standard methods of RFC 8620 section 5
+// can be called for it with typed arguments and results.
+//
+// Adding a data type only takes a struct for its objects and a Type value:
+//
+//	type Thread struct {
+//		ID       string   `json:"id"`
+//		EmailIDs []string `json:"emailIds"`
+//	}
+//
+//	var Threads = requests.Type[Thread]{Name: "Thread"}
+//
+//	resp, err := Threads.Get(ctx, c, acctID, &requests.GetArgs{IDs: ids})
+type Type[T any] struct {
+	Name  string       // the method name prefix, e.g. "Mailbox"
+	Using []Capability // capabilities the type needs beyond core and mail, e.g. UsingSubmission for Identity
+}
+
+// GetArgs are the arguments of Foo/get.
+type GetArgs struct {
+	IDs        []string       // nil fetches every object
+	Properties []string       // nil fetches every property
+	Extra      map[string]any // type specific arguments, e.g. fetchTextBodyValues for Email/get
+}
+
+// GetResponse is the response to Foo/get.
+type GetResponse[T any] struct {
+	AccountID string   `json:"accountId"`
+	State     string   `json:"state"`
+	List      []*T     `json:"list"`
+	NotFound  []string `json:"notFound"`
+}
+
+// Patch is a PatchObject, mapping JSON pointers to the values they are set to.
+// A nil value resets the property to its default.
+type Patch map[string]any
+
+// SetArgs are the arguments of Foo/set.
+type SetArgs[T any] struct {
+	IfInState string
+	Create    map[string]*T // keyed by creation id
+	Update    map[string]Patch
+	Destroy   []string
+	Extra     map[string]any // type specific arguments, e.g. onDestroyRemoveEmails for Mailbox/set
+}
+
+// SetResponse is the response to Foo/set.
+//
+// Created holds only the properties the server set or changed, such as the id.
+// Updated maps each updated id to the properties the server changed, which is often nil.
+type SetResponse[T any] struct {
+	AccountID    string               `json:"accountId"`
+	OldState     string               `json:"oldState"`
+	NewState     string               `json:"newState"`
+	Created      map[string]*T        `json:"created"`
+	Updated      map[string]*T        `json:"updated"`
+	Destroyed    []string             `json:"destroyed"`
+	NotCreated   map[string]*SetError `json:"notCreated"`
+	NotUpdated   map[string]*SetError `json:"notUpdated"`
+	NotDestroyed map[string]*SetError `json:"notDestroyed"`
+}
+
+// Err returns an error joining an *ObjectError for every object that wasn't created,
+// updated or destroyed, or nil when every change was made.
+func (r *SetResponse[T]) Err() error {
+	var errs []error
+	for _, op := range []struct {
+		name   string
+		failed map[string]*SetError
+	}{{"create", r.NotCreated}, {"update", r.NotUpdated}, {"destroy", r.NotDestroyed}} {
+		ids := make([]string, 0, len(op.failed))
+		for id := range op.failed {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			errs = append(errs, &ObjectError{ID: id, Op: op.name, Err: op.failed[id]})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Comparator is one level of sorting in Foo/query.
+type Comparator struct {
+	Property    string `json:"property"`
+	IsAscending bool   `json:"isAscending"`
+	Collation   string `json:"collation,omitempty"`
+}
+
+// QueryArgs are the arguments of Foo/query.
+type QueryArgs struct {
+	Filter         any // a type specific FilterCondition or FilterOperator
+	Sort           []*Comparator
+	Position       int
+	Anchor         string
+	AnchorOffset   int
+	Limit          int // zero leaves the limit to the server
+	CalculateTotal bool
+	Extra          map[string]any // type specific arguments, e.g. collapseThreads for Email/query
+}
+
+// QueryResponse is the response to Foo/query.
+type QueryResponse struct {
+	AccountID           string   `json:"accountId"`
+	QueryState          string   `json:"queryState"`
+	CanCalculateChanges bool     `json:"canCalculateChanges"`
+	Position            int      `json:"position"`
+	IDs                 []string `json:"ids"`
+	Total               *int     `json:"total"` // only set when CalculateTotal was requested
+	Limit               *int     `json:"limit"` // only set when the server lowered the requested limit
+}
+
+// QueryChangesArgs are the arguments of Foo/queryChanges.
+// Filter, Sort and Extra must be the same as those of the original query.
+type QueryChangesArgs struct {
+	SinceQueryState string
+	Filter          any
+	Sort            []*Comparator
+	MaxChanges      int    // zero leaves the limit to the server
+	UpToID          string // the last id the caller holds, when it only holds the top of the results
+	CalculateTotal  bool
+	Extra           map[string]any
+}
+
+// AddedItem is an id inserted into query results, at its index in the new results.
+type AddedItem struct {
+	ID    string `json:"id"`
+	Index int    `json:"index"`
+}
+
+// QueryChangesResponse is the response to Foo/queryChanges.
+type QueryChangesResponse struct {
+	AccountID     string       `json:"accountId"`
+	OldQueryState string       `json:"oldQueryState"`
+	NewQueryState string       `json:"newQueryState"`
+	Total         *int         `json:"total"` // only set when CalculateTotal was requested
+	Removed       []string     `json:"removed"`
+	Added         []*AddedItem `json:"added"`
+}
+
+// ChangesArgs are the arguments of Foo/changes.
+type ChangesArgs struct {
+	SinceState string
+	MaxChanges int // zero leaves the limit to the server
+}
+
+// ChangesResponse is the response to Foo/changes.
+type ChangesResponse struct {
+	AccountID      string   `json:"accountId"`
+	OldState       string   `json:"oldState"`
+	NewState       string   `json:"newState"`
+	HasMoreChanges bool     `json:"hasMoreChanges"`
+	Created        []string `json:"created"`
+	Updated        []string `json:"updated"`
+	Destroyed      []string `json:"destroyed"`
+}
+
+// GetCall constructs a Foo/get call.
+func (t Type[T]) GetCall(acctID string, args *GetArgs) (*Call, error) {
+	if args == nil {
+		args = &GetArgs{}
+	}
+	m := map[string]any{"ids": args.IDs}
+	if args.Properties != nil {
+		m["properties"] = args.Properties
+	}
+	return t.call("get", acctID, m, args.Extra), nil
+}
+
+// SetCall constructs a Foo/set call.
+func (t Type[T]) SetCall(acctID string, args *SetArgs[T]) (*Call, error) {
+	if args == nil || len(args.Create)+len(args.Update)+len(args.Destroy) < 1 {
+		return nil, fmt.Errorf("nothing to create, update or destroy")
+	}
+	m := map[string]any{}
+	if len(args.IfInState) > 0 {
+		m["ifInState"] = args.IfInState
+	}
+	if len(args.Create) > 0 {
+		m["create"] = args.Create
+	}
+	if len(args.Update) > 0 {
+		m["update"] = args.Update
+	}
+	if len(args.Destroy) > 0 {
+		m["destroy"] = args.Destroy
+	}
+	return t.call("set", acctID, m, args.Extra), nil
+}
+
+// QueryCall constructs a Foo/query call.
+func (t Type[T]) QueryCall(acctID string, args *QueryArgs) (*Call, error) {
+	if args == nil {
+		args = &QueryArgs{}
+	}
+	m := map[string]any{}
+	if args.Filter != nil {
+		m["filter"] = args.Filter
+	}
+	if len(args.Sort) > 0 {
+		m["sort"] = args.Sort
+	}
+	if args.Position != 0 {
+		m["position"] = args.Position
+	}
+	if len(args.Anchor) > 0 {
+		m["anchor"] = args.Anchor
+		m["anchorOffset"] = args.AnchorOffset
+	}
+	if args.Limit > 0 {
+		m["limit"] = args.Limit
+	}
+	if args.CalculateTotal {
+		m["calculateTotal"] = true
+	}
+	return t.call("query", acctID, m, args.Extra), nil
+}
+
+// QueryChangesCall constructs a Foo/queryChanges call.
+func (t Type[T]) QueryChangesCall(acctID string, args *QueryChangesArgs) (*Call, error) {
+	if args == nil || len(args.SinceQueryState) < 1 {
+		return nil, fmt.Errorf("args.SinceQueryState must be populated")
+	}
+	m := map[string]any{"sinceQueryState": args.SinceQueryState}
+	if args.Filter != nil {
+		m["filter"] = args.Filter
+	}
+	if len(args.Sort) > 0 {
+		m["sort"] = args.Sort
+	}
+	if args.MaxChanges > 0 {
+		m["maxChanges"] = args.MaxChanges
+	}
+	if len(args.UpToID) > 0 {
+		m["upToId"] = args.UpToID
+	}
+	if args.CalculateTotal {
+		m["calculateTotal"] = true
+	}
+	return t.call("queryChanges", acctID, m, args.Extra), nil
+}
+
+// ChangesCall constructs a Foo/changes call.
+func (t Type[T]) ChangesCall(acctID string, args *ChangesArgs) (*Call, error) {
+	if args == nil || len(args.SinceState) < 1 {
+		return nil, fmt.Errorf("args.SinceState must be populated")
+	}
+	m := map[string]any{"sinceState": args.SinceState}
+	if args.MaxChanges > 0 {
+		m["maxChanges"] = args.MaxChanges
+	}
+	return t.call("changes", acctID, m, nil), nil
+}
+
+// Get sends a Foo/get call in its own request.
+func (t Type[T]) Get(ctx context.Context, c *client.Client, acctID string, args *GetArgs) (*GetResponse[T], error) {
+	call, err := t.GetCall(acctID, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct %s call: %w", t.method("get"), err)
+	}
+	return SendCall[GetResponse[T]](ctx, c, call, Using(t.Using...))
+}
+
+// Set sends a Foo/set call in its own request.
+func (t Type[T]) Set(ctx context.Context, c *client.Client, acctID string, args *SetArgs[T]) (*SetResponse[T], error) {
+	call, err := t.SetCall(acctID, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct %s call: %w", t.method("set"), err)
+	}
+	return SendCall[SetResponse[T]](ctx, c, call, Using(t.Using...))
+}
+
+// Query sends a Foo/query call in its own request.
+func (t Type[T]) Query(ctx context.Context, c *client.Client, acctID string, args *QueryArgs) (*QueryResponse, error) {
+	call, err := t.QueryCall(acctID, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct %s call: %w", t.method("query"), err)
+	}
+	return SendCall[QueryResponse](ctx, c, call, Using(t.Using...))
+}
+
+// QueryChanges sends a Foo/queryChanges call in its own request.
+func (t Type[T]) QueryChanges(ctx context.Context, c *client.Client, acctID string, args *QueryChangesArgs) (*QueryChangesResponse, error) {
+	call, err := t.QueryChangesCall(acctID, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct %s call: %w", t.method("queryChanges"), err)
+	}
+	return SendCall[QueryChangesResponse](ctx, c, call, Using(t.Using...))
+}
+
+// Changes sends a Foo/changes call in its own request.
+func (t Type[T]) Changes(ctx context.Context, c *client.Client, acctID string, args *ChangesArgs) (*ChangesResponse, error) {
+	call, err := t.ChangesCall(acctID, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct %s call: %w", t.method("changes"), err)
+	}
+	return SendCall[ChangesResponse](ctx, c, call, Using(t.Using...))
+}
+
+func (t Type[T]) method(name string) string {
+	return t.Name + "/" + name
+}
+
+func (t Type[T]) call(name string, acctID string, args map[string]any, extra map[string]any) *Call {
+	for k, v := range extra {
+		args[k] = v
+	}
+	return &Call{
+		ID:        NewCallID(),
+		AccountID: acctID,
+		Method:    t.method(name),
+		Arguments: args,
+	}
+}
+
+// SendCall sends call in its own request with opts and decodes its response into R,
+// for methods outside the standard ones of Type.
+func SendCall[R any](ctx context.Context, c *client.Client, call *Call, opts ...Option) (*R, error) {
+	responses, err := RequestWithContext(ctx, c, []*Call{call}, false, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%s request failure: %w", call.Method, err)
+	}
+	for _, resp := range responses {
+		if resp.ID == call.ID && resp.Method == call.Method {
+			var result R
+			if err := resp.Decode(&result); err != nil {
+				return nil, err
+			}
+			return &result, nil
+		}
+	}
+	return nil, fmt.Errorf("no %s response returned", call.Method)
+}
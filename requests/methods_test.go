@@ -0,0 +1,160 @@
+package requests_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cwinters8/gomap/internal/jmaptest"
+	"github.com/cwinters8/gomap/requests"
+	"github.com/cwinters8/gomap/utils"
+)
+
+type thing struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+var things = requests.Type[thing]{Name: "Thing"}
+
+// thingAPI answers the standard methods for Thing objects, keeping the arguments of the last call.
+func thingAPI(last *map[string]any) func(method string, args map[string]any) (string, map[string]any) {
+	return func(method string, args map[string]any) (string, map[string]any) {
+		*last = args
+		switch method {
+		case "Thing/get":
+			return method, map[string]any{
+				"accountId": args["accountId"],
+				"state":     "s1",
+				"list":      []any{map[string]any{"id": "T1", "name": "one"}},
+				"notFound":  []any{"T9"},
+			}
+		case "Thing/set":
+			return method, map[string]any{
+				"oldState":   "s1",
+				"newState":   "s2",
+				"created":    map[string]any{"new": map[string]any{"id": "T2"}},
+				"updated":    map[string]any{"T1": nil},
+				"notCreated": map[string]any{"bad": map[string]any{"type": "invalidProperties", "properties": []any{"name"}}},
+			}
+		case "Thing/query":
+			return method, map[string]any{"queryState": "q1", "ids": []any{"T1", "T2"}, "position": 0, "total": 2}
+		case "Thing/changes":
+			return method, map[string]any{"oldState": "s1", "newState": "s2", "hasMoreChanges": true, "created": []any{"T2"}, "updated": []any{}, "destroyed": []any{"T3"}}
+		}
+		return "error", map[string]any{"type": "unknownMethod"}
+	}
+}
+
+func TestType(t *testing.T) {
+	var last map[string]any
+	f := jmaptest.NewServer(t, thingAPI(&last))
+	c := f.NewClient()
+	ctx := context.Background()
+
+	t.Run("get", func(t *testing.T) {
+		resp, err := things.Get(ctx, c, "u1", &requests.GetArgs{
+			IDs:        []string{"T1", "T9"},
+			Properties: []string{"name"},
+			Extra:      map[string]any{"fetchAll": true},
+		})
+		if err != nil {
+			t.Fatalf("get failure: %s", err.Error())
+		}
+		cases := utils.Cases{
+			utils.NewCase(len(resp.List) != 1 || resp.List[0].Name != "one", "wanted thing named one; got %v", resp.List),
+			utils.NewCase(len(resp.NotFound) != 1, "wanted 1 id not found; got %v", resp.NotFound),
+			utils.NewCase(resp.AccountID != "u1", "wanted account id u1; got %s", resp.AccountID),
+			utils.NewCase(last["fetchAll"] != true, "wanted extra argument to be sent; got %v", last),
+		}
+		cases.Iterator(func(c *utils.Case) {
+			t.Error(c.Message)
+		})
+	})
+
+	t.Run("get all", func(t *testing.T) {
+		if _, err := things.Get(ctx, c, "u1", nil); err != nil {
+			t.Fatalf("get failure: %s", err.Error())
+		}
+		ids, ok := last["ids"]
+		if !ok || ids != nil {
+			t.Errorf("wanted ids to be sent as null; got %v", last)
+		}
+	})
+
+	t.Run("set", func(t *testing.T) {
+		resp, err := things.Set(ctx, c, "u1", &requests.SetArgs[thing]{
+			IfInState: "s1",
+			Create:    map[string]*thing{"new": {Name: "two"}, "bad": {}},
+			Update:    map[string]requests.Patch{"T1": {"name": "uno"}},
+		})
+		if err != nil {
+			t.Fatalf("set failure: %s", err.Error())
+		}
+		_, destroy := last["destroy"]
+		cases := utils.Cases{
+			utils.NewCase(resp.Created["new"] == nil || resp.Created["new"].ID != "T2", "wanted created thing T2; got %v", resp.Created),
+			utils.NewCase(resp.NewState != "s2", "wanted new state s2; got %s", resp.NewState),
+			utils.NewCase(last["ifInState"] != "s1", "wanted ifInState s1; got %v", last["ifInState"]),
+			utils.NewCase(destroy, "wanted empty destroy to be left out"),
+		}
+		if _, ok := resp.Updated["T1"]; !ok {
+			cases.Append(utils.NewCase(true, "wanted T1 to be updated; got %v", resp.Updated))
+		}
+		var setErr *requests.SetError
+		bad := resp.NotCreated["bad"]
+		if bad == nil || !errors.As(error(bad), &setErr) || setErr.Type != requests.SetErrorInvalidProperties {
+			cases.Append(utils.NewCase(true, "wanted invalidProperties set error; got %v", resp.NotCreated))
+		}
+		cases.Iterator(func(c *utils.Case) {
+			t.Error(c.Message)
+		})
+	})
+
+	t.Run("empty set", func(t *testing.T) {
+		if _, err := things.SetCall("u1", &requests.SetArgs[thing]{}); err == nil {
+			t.Error("wanted an error for a set call without changes")
+		}
+	})
+
+	t.Run("query", func(t *testing.T) {
+		resp, err := things.Query(ctx, c, "u1", &requests.QueryArgs{
+			Filter:         map[string]string{"name": "one"},
+			Sort:           []*requests.Comparator{{Property: "name", IsAscending: true}},
+			Limit:          10,
+			CalculateTotal: true,
+		})
+		if err != nil {
+			t.Fatalf("query failure: %s", err.Error())
+		}
+		_, position := last["position"]
+		cases := utils.Cases{
+			utils.NewCase(len(resp.IDs) != 2, "wanted 2 ids; got %v", resp.IDs),
+			utils.NewCase(resp.Total == nil || *resp.Total != 2, "wanted total 2; got %v", resp.Total),
+			utils.NewCase(resp.QueryState != "q1", "wanted query state q1; got %s", resp.QueryState),
+			utils.NewCase(last["limit"] != float64(10), "wanted limit 10; got %v", last["limit"]),
+			utils.NewCase(position, "wanted zero position to be left out"),
+		}
+		cases.Iterator(func(c *utils.Case) {
+			t.Error(c.Message)
+		})
+	})
+
+	t.Run("changes", func(t *testing.T) {
+		resp, err := things.Changes(ctx, c, "u1", &requests.ChangesArgs{SinceState: "s1", MaxChanges: 50})
+		if err != nil {
+			t.Fatalf("changes failure: %s", err.Error())
+		}
+		cases := utils.Cases{
+			utils.NewCase(!resp.HasMoreChanges, "wanted more changes"),
+			utils.NewCase(len(resp.Created) != 1 || len(resp.Destroyed) != 1, "wanted 1 created and 1 destroyed; got %v", resp),
+			utils.NewCase(last["maxChanges"] != float64(50), "wanted maxChanges 50; got %v", last["maxChanges"]),
+		}
+		cases.Iterator(func(c *utils.Case) {
+			t.Error(c.Message)
+		})
+		if _, err := things.ChangesCall("u1", &requests.ChangesArgs{}); err == nil {
+			t.Error("wanted an error for a changes call without a state")
+		}
+	})
+}
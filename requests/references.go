@@ -0,0 +1,62 @@
+package requests
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ResultReference points at part of the result of an earlier call in the same request,
+// letting the server fill in an argument before running the call (RFC 8620 section 3.7).
+//
+// Path is a JSON pointer into the referenced response, e.g. "/ids" or "/list/*/threadId".
+type ResultReference struct {
+	ResultOf string `json:"resultOf"`
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+}
+
+// Reference returns a ResultReference to path in the response to c.
+func (c *Call) Reference(path string) *ResultReference {
+	return &ResultReference{
+		ResultOf: c.ID,
+		Name:     c.Method,
+		Path:     path,
+	}
+}
+
+// SetReference sets the argument name to the value found at ref,
+// replacing any literal value previously given for name.
+//
+//	query, _ := emails.QueryCall(acctID, &filter)
+//	get, _ := emails.GetCall(acctID, nil)
+//	get.SetReference("ids", query.Reference("/ids"))
+func (c *Call) SetReference(name string, ref *ResultReference) error {
+	if c.Arguments == nil {
+		return fmt.Errorf("c.Arguments field must not be nil")
+	}
+	if ref == nil {
+		return fmt.Errorf("ref must not be nil")
+	}
+	delete(c.Arguments, name)
+	c.Arguments["#"+name] = ref
+	return nil
+}
+
+// references returns the ids of the calls that c refers to.
+func (c *Call) references() []string {
+	ids := []string{}
+	for k, v := range c.Arguments {
+		if !strings.HasPrefix(k, "#") {
+			continue
+		}
+		switch ref := v.(type) {
+		case *ResultReference:
+			if ref != nil {
+				ids = append(ids, ref.ResultOf)
+			}
+		case ResultReference:
+			ids = append(ids, ref.ResultOf)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,171 @@
+package requests_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/cwinters8/gomap/client"
+	"github.com/cwinters8/gomap/internal/jmaptest"
+	"github.com/cwinters8/gomap/requests"
+	"github.com/cwinters8/gomap/utils"
+)
+
+func TestResultReference(t *testing.T) {
+	query := requests.Call{
+		ID:        requests.NewCallID(),
+		AccountID: "xyz",
+		Method:    "Mailbox/query",
+		Arguments: map[string]any{"filter": map[string]string{"role": "inbox"}},
+	}
+	get := requests.Call{
+		ID:        requests.NewCallID(),
+		AccountID: "xyz",
+		Method:    "Mailbox/get",
+		Arguments: map[string]any{"ids": []string{"stale"}},
+	}
+	if err := get.SetReference("ids", query.Reference("/ids")); err != nil {
+		t.Fatalf("failed to set reference: %s", err.Error())
+	}
+	b, err := json.Marshal(get)
+	if err != nil {
+		t.Fatalf("failed to marshal call to json: %s", err.Error())
+	}
+	var slice []json.RawMessage
+	if err := json.Unmarshal(b, &slice); err != nil {
+		t.Fatalf("failed to unmarshal call to slice: %s", err.Error())
+	}
+	var args struct {
+		IDs *[]string                 `json:"ids"`
+		Ref *requests.ResultReference `json:"#ids"`
+	}
+	if err := json.Unmarshal(slice[1], &args); err != nil {
+		t.Fatalf("failed to unmarshal arguments: %s", err.Error())
+	}
+	if args.Ref == nil {
+		t.Fatalf("wanted #ids argument; got %s", slice[1])
+	}
+	cases := utils.Cases{
+		utils.NewCase(args.IDs != nil, "wanted literal ids to be replaced by the reference"),
+		utils.NewCase(args.Ref.ResultOf != query.ID, "wanted resultOf %s; got %s", query.ID, args.Ref.ResultOf),
+		utils.NewCase(args.Ref.Name != "Mailbox/query", "wanted name Mailbox/query; got %s", args.Ref.Name),
+		utils.NewCase(args.Ref.Path != "/ids", "wanted path /ids; got %s", args.Ref.Path),
+	}
+	cases.Iterator(func(c *utils.Case) {
+		t.Error(c.Message)
+	})
+
+	t.Run("kept in one request", func(t *testing.T) {
+		f := jmaptest.NewServer(t, func(method string, args map[string]any) (string, map[string]any) {
+			return method, map[string]any{"ids": []any{}, "list": []any{}}
+		})
+		c := withLimits(f, &client.CoreCapabilities{MaxCallsInRequest: 2})
+		other := requests.Call{
+			ID:        requests.NewCallID(),
+			AccountID: "xyz",
+			Method:    "Identity/get",
+			Arguments: map[string]any{},
+		}
+		if _, err := requests.Request(c, []*requests.Call{&other, &query, &get}, false); err != nil {
+			t.Fatalf("request failure: %s", err.Error())
+		}
+		if len(f.Requests()) != 2 {
+			t.Fatalf("wanted 2 requests; got %d", len(f.Requests()))
+		}
+		second := f.Requests()[1]["methodCalls"].([]any)
+		if len(second) != 2 {
+			t.Errorf("wanted query and get in the same request; got %d calls", len(second))
+		}
+	})
+
+	t.Run("order", func(t *testing.T) {
+		f := jmaptest.NewServer(t, func(method string, args map[string]any) (string, map[string]any) {
+			return method, map[string]any{"ids": []any{}, "list": []any{}}
+		})
+		set := requests.Call{
+			ID:        requests.NewCallID(),
+			AccountID: "xyz",
+			Method:    "Mailbox/set",
+			Arguments: map[string]any{"update": map[string]any{"MB1": map[string]any{"role": "inbox"}}},
+		}
+		sent := func() []string {
+			methods := []string{}
+			for _, req := range f.Requests() {
+				for _, call := range req["methodCalls"].([]any) {
+					methods = append(methods, call.([]any)[0].(string))
+				}
+			}
+			return methods
+		}
+
+		if _, err := requests.Request(f.NewClient(), []*requests.Call{&query, &set, &get}, false); err != nil {
+			t.Fatalf("request failure: %s", err.Error())
+		}
+		got := sent()
+		cases := utils.Cases{
+			utils.NewCase(len(f.Requests()) != 1, "wanted 1 request; got %d", len(f.Requests())),
+			utils.NewCase(fmt.Sprint(got) != "[Mailbox/query Mailbox/set Mailbox/get]", "wanted calls in their original order; got %v", got),
+		}
+
+		// the three calls don't fit in one request, and the query can't move past the set
+		f.Reset()
+		limited := withLimits(f, &client.CoreCapabilities{MaxCallsInRequest: 2})
+		if _, err := requests.Request(limited, []*requests.Call{&query, &set, &get}, false); err == nil {
+			t.Error("wanted an error for linked calls that can't be kept together without moving them past a set")
+		}
+		cases.Append(utils.NewCase(len(f.Requests()) != 0, "wanted nothing sent; got %d requests", len(f.Requests())))
+
+		// reads may be reordered, so the referenced query moves after the identities
+		identities := requests.Call{
+			ID:        requests.NewCallID(),
+			AccountID: "xyz",
+			Method:    "Identity/get",
+			Arguments: map[string]any{},
+		}
+		if _, err := requests.Request(limited, []*requests.Call{&query, &identities, &get}, false); err != nil {
+			t.Fatalf("request failure: %s", err.Error())
+		}
+		got = sent()
+		cases.Append(
+			utils.NewCase(len(f.Requests()) != 2, "wanted 2 requests; got %d", len(f.Requests())),
+			utils.NewCase(fmt.Sprint(got) != "[Identity/get Mailbox/query Mailbox/get]", "wanted the identities before the query and get; got %v", got),
+		)
+		cases.Iterator(func(c *utils.Case) {
+			t.Error(c.Message)
+		})
+	})
+
+	t.Run("writes", func(t *testing.T) {
+		f := jmaptest.NewServer(t, func(method string, args map[string]any) (string, map[string]any) {
+			return method, map[string]any{"list": []any{}}
+		})
+		parent := requests.Call{
+			ID:        requests.NewCallID(),
+			AccountID: "xyz",
+			Method:    "Mailbox/set",
+			Arguments: map[string]any{"create": map[string]any{"p": map[string]any{"name": "Parent"}}},
+		}
+		child := requests.Call{
+			ID:        requests.NewCallID(),
+			AccountID: "xyz",
+			Method:    "Mailbox/set",
+			Arguments: map[string]any{"create": map[string]any{"c": map[string]any{"name": "Child", "parentId": "#p"}}},
+		}
+		created := requests.Call{
+			ID:        requests.NewCallID(),
+			AccountID: "xyz",
+			Method:    "Mailbox/get",
+			Arguments: map[string]any{},
+		}
+		if err := created.SetReference("ids", parent.Reference("/created/p/id")); err != nil {
+			t.Fatalf("failed to set reference: %s", err.Error())
+		}
+		c := withLimits(f, &client.CoreCapabilities{MaxCallsInRequest: 2})
+		if _, err := requests.Request(c, []*requests.Call{&parent, &child, &created}, false); err == nil {
+			t.Error("wanted an error rather than creating the child before its parent")
+		}
+		if len(f.Requests()) != 0 {
+			t.Errorf("wanted nothing sent; got %d requests", len(f.Requests()))
+		}
+	})
+}
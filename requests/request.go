@@ -1,104 +1,293 @@
 package requests
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
-	"os"
+	"slices"
 
 	"github.com/cwinters8/gomap/client"
-	"github.com/cwinters8/gomap/utils"
-	"github.com/google/uuid"
 )
 
-func Request(c *client.Client, calls []*Call, usingSubmission bool) ([]*Response, error) {
+// Request sends calls to the JMAP API in a single request.
+//
+// Calls are split across several requests when they would exceed the limits advertised
+// in the session's core capabilities, and the responses are merged back together,
+// so each call still receives a single response.
+//
+// If any call fails, the returned error is a MethodErrors value holding a *MethodError
+// for each failure. By default no responses are returned alongside method errors;
+// pass PartialResults to receive the successful responses as well.
+func Request(c *client.Client, calls []*Call, usingSubmission bool, opts ...Option) ([]*Response, error) {
+	return RequestWithContext(context.Background(), c, calls, usingSubmission, opts...)
+}
+
+// RequestWithContext is like Request, but the underlying http request is bound to ctx.
+func RequestWithContext(ctx context.Context, c *client.Client, calls []*Call, usingSubmission bool, opts ...Option) ([]*Response, error) {
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
 	using := []Capability{UsingCore, UsingMail}
 	if usingSubmission {
 		using = append(using, UsingSubmission)
 	}
-	r := Req{
-		Using: using,
-		Calls: calls,
+	for _, capability := range o.using {
+		if !slices.Contains(using, capability) {
+			using = append(using, capability)
+		}
 	}
-	b, err := json.Marshal(r)
+	l := newLimits(c.Session, using)
+	length, err := createdLength(o.createdIDs)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request to json: %w", err)
+		return nil, err
 	}
-	status, result, err := c.HttpRequest(http.MethodPost, c.Session.APIURL, b)
+	l.createdLength = length
+	batches, chunks, err := l.plan(calls)
 	if err != nil {
-		return nil, fmt.Errorf("status %d - http request failure: %w", status, err)
+		return nil, fmt.Errorf("failed to fit calls within server limits: %w", err)
 	}
-	if os.Getenv("REQUEST_DEBUG") == "true" {
-		// write raw json to file to allow for examination of full request and response
-		raw := map[string][]byte{
-			"request":  b,
-			"response": result,
+	// createdIds carries creation ids between the requests of a split call list,
+	// so later calls can still refer to objects created by earlier ones
+	created := o.createdIDs
+	if created == nil && len(batches) > 1 {
+		created = map[string]string{}
+	}
+	responses := []*Response{}
+	errs := MethodErrors{}
+	for i := 0; i < len(batches); i++ {
+		// createdIds grows with every request, so the calls left may no longer fit as planned
+		if batches, chunks, err = replan(&l, batches, i, chunks, calls, created); err != nil {
+			return nil, fmt.Errorf("failed to fit calls within server limits: %w", err)
 		}
-		if err := utils.WriteJSON("jmap", "../tmp/raw", raw); err != nil {
-			fmt.Printf("warning: failed to write json response to file: %s\n", err.Error())
+		batch := batches[i]
+		batchResponses, batchErrs, err := send(ctx, c, using, batch, calls, created)
+		if err != nil {
+			return nil, err
 		}
+		responses = append(responses, batchResponses...)
+		for _, e := range batchErrs {
+			// report errors from a chunk against the call it came from
+			if id, ok := chunks[e.CallID]; ok {
+				e.CallID = id
+			}
+			for _, call := range calls {
+				if call.ID == e.CallID {
+					e.Method = call.Method
+					break
+				}
+			}
+		}
+		errs = append(errs, batchErrs...)
 	}
-	var resp Resp
-	if err := json.Unmarshal(result, &resp); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	responses, err = mergeResponses(responses, chunks)
+	if err != nil {
+		return nil, err
 	}
-	errs := []map[string]any{}
-	responses := []*Response{}
-	for _, r := range resp.MethodResponses {
-		method, ok := r[0].(string)
-		if !ok {
-			return nil, fmt.Errorf("failed to cast method as string. %s", utils.Describe(r[0]))
+	for _, resp := range responses {
+		for _, c := range calls {
+			if c.ID != resp.ID {
+				continue
+			}
+			if c.OnSuccess != nil && c.Method == resp.Method {
+				if err := c.OnSuccess(resp.Body); err != nil {
+					return nil, fmt.Errorf("call to OnSuccess failed: %w", err)
+				}
+			}
+			if c.OnResponse != nil {
+				if err := c.OnResponse(resp); err != nil {
+					return nil, fmt.Errorf("call to OnResponse failed: %w", err)
+				}
+			}
+			break
 		}
-		body, ok := r[1].(map[string]any)
-		if !ok {
-			return nil, fmt.Errorf("failed to cast response body to map. %s", utils.Describe(r[1]))
+	}
+	if len(errs) > 0 {
+		if o.partial {
+			return responses, errs
 		}
-		idStr, ok := r[2].(string)
-		if !ok {
-			return nil, fmt.Errorf("failed to cast id to string. %s", utils.Describe(r[2]))
+		return nil, errs
+	}
+	return responses, nil
+}
+
+// send makes a single request containing batch, retrying it when the server is
+// temporarily unavailable. calls is the full list of calls used for describing errors.
+//
+// When created is not nil it is sent as the request's createdIds and
+// updated with the createdIds of the response.
+func send(ctx context.Context, c *client.Client, using []Capability, batch []*Call, calls []*Call, created map[string]string) ([]*Response, MethodErrors, error) {
+	r := Req{
+		Using:      using,
+		Calls:      batch,
+		CreatedIDs: created,
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal request to json: %w", err)
+	}
+	idempotent := true
+	for _, call := range batch {
+		if !call.Idempotent() {
+			idempotent = false
+			break
 		}
-		if method == "error" {
-			body["id"] = idStr
-			errs = append(errs, body)
-			continue
+	}
+	for attempt := 0; ; attempt++ {
+		status, result, err := c.APIRequest(ctx, b, idempotent)
+		if err != nil {
+			return nil, nil, fmt.Errorf("status %d - http request failure: %w", status, err)
 		}
-		parsedID, err := uuid.Parse(idStr)
+		responses, errs, err := parseResponses(result, calls, created)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse uuid from `%s`: %w", idStr, err)
+			return nil, nil, err
 		}
-		responses = append(responses, &Response{
-			ID:     parsedID,
-			Method: method,
-			Body:   body,
-		})
-		for _, c := range calls {
-			if c.ID.String() == idStr && c.Method == method {
-				if c.OnSuccess != nil {
-					if err := c.OnSuccess(body); err != nil {
-						return nil, fmt.Errorf("call to OnSuccess failed: %w", err)
-					}
-				}
-				break
+		// serverUnavailable is temporary, so the whole request can be sent again
+		// as long as doing so can't repeat any changes
+		if idempotent && errs.has(ErrorServerUnavailable) && c.Retry != nil && attempt+1 < c.Retry.MaxAttempts {
+			if err := c.Retry.Wait(ctx, attempt, 0); err != nil {
+				return nil, nil, fmt.Errorf("stopped retrying after attempt %d: %w", attempt+1, err)
 			}
+			continue
 		}
+		return responses, errs, nil
 	}
-	if len(errs) > 0 {
-		return nil, fmt.Errorf("found method errors: %v", errs)
+}
+
+// parseResponses reads the method responses from result,
+// copying the response's createdIds into created when it is not nil.
+func parseResponses(result []byte, calls []*Call, created map[string]string) ([]*Response, MethodErrors, error) {
+	var resp Resp
+	if err := json.Unmarshal(result, &resp); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if created != nil {
+		for k, v := range resp.CreatedIDs {
+			created[k] = v
+		}
+	}
+	errs := MethodErrors{}
+	responses := []*Response{}
+	for _, r := range resp.MethodResponses {
+		if r.Method == "error" {
+			methodErr, err := parseMethodError(r.ID, r.Body, calls)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to parse method error: %w", err)
+			}
+			errs = append(errs, methodErr)
+			continue
+		}
+		responses = append(responses, r)
+	}
+	return responses, errs, nil
+}
+
+// Option configures optional behavior of Request.
+type Option func(*options)
+
+type options struct {
+	partial    bool
+	createdIDs map[string]string
+	using      []Capability
+}
+
+// PartialResults makes Request return the successful responses
+// alongside any MethodErrors instead of discarding them.
+func PartialResults() Option {
+	return func(o *options) {
+		o.partial = true
+	}
+}
+
+// Using adds capabilities to the request's using list, for methods
+// beyond those of core, mail and submission, such as the Blob methods of UsingBlob.
+func Using(capabilities ...Capability) Option {
+	return func(o *options) {
+		o.using = append(o.using, capabilities...)
+	}
+}
+
+// CreatedIDs sends ids as the request's createdIds, mapping creation ids to the server ids
+// of objects created in earlier requests, so calls can refer to them as "#creationId".
+// ids is updated in place with the createdIds the server returns, including ids
+// for objects created by this request, and can be passed to the next request.
+//
+// ids must not be nil.
+func CreatedIDs(ids map[string]string) Option {
+	return func(o *options) {
+		o.createdIDs = ids
 	}
-	return responses, nil
+}
+
+func parseMethodError(callID string, body json.RawMessage, calls []*Call) (*MethodError, error) {
+	var methodErr MethodError
+	if err := json.Unmarshal(body, &methodErr); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal error body: %w", err)
+	}
+	methodErr.CallID = callID
+	for _, c := range calls {
+		if c.ID == callID {
+			methodErr.Method = c.Method
+			break
+		}
+	}
+	return &methodErr, nil
 }
 
 type Req struct {
-	Using []Capability `json:"using"`
-	Calls []*Call      `json:"methodCalls"`
+	Using      []Capability      `json:"using"`
+	Calls      []*Call           `json:"methodCalls"`
+	CreatedIDs map[string]string `json:"-"` // sent whenever it is not nil, even when empty
+}
+
+func (r Req) MarshalJSON() ([]byte, error) {
+	type plain Req
+	if r.CreatedIDs == nil {
+		return json.Marshal(plain(r))
+	}
+	return json.Marshal(struct {
+		plain
+		CreatedIDs map[string]string `json:"createdIds"`
+	}{plain(r), r.CreatedIDs})
 }
 
 type Resp struct {
-	MethodResponses [][3]any `json:"methodResponses"`
+	MethodResponses []*Response       `json:"methodResponses"`
+	CreatedIDs      map[string]string `json:"createdIds"`
+	SessionState    string            `json:"sessionState"`
 }
 
+// Response is a single method response. Body is left undecoded
+// so it can be decoded straight into the type the caller expects.
 type Response struct {
-	ID     uuid.UUID
+	ID     string
 	Method string
-	Body   map[string]any
+	Body   json.RawMessage
+}
+
+// Decode unmarshals the body of r into v.
+func (r *Response) Decode(v any) error {
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", r.Method, err)
+	}
+	return nil
+}
+
+// UnmarshalJSON decodes a response from its [name, arguments, id] invocation form.
+func (r *Response) UnmarshalJSON(b []byte) error {
+	var raw [3]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return fmt.Errorf("failed to unmarshal method response: %w", err)
+	}
+	if err := json.Unmarshal(raw[0], &r.Method); err != nil {
+		return fmt.Errorf("failed to unmarshal method name: %w", err)
+	}
+	if err := json.Unmarshal(raw[2], &r.ID); err != nil {
+		return fmt.Errorf("failed to unmarshal call id: %w", err)
+	}
+	if len(raw[1]) < 1 || raw[1][0] != '{' {
+		return fmt.Errorf("method response arguments must be an object; got %s", raw[1])
+	}
+	r.Body = raw[1]
+	return nil
 }
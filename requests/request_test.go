@@ -1,10 +1,14 @@
 package requests_test
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/cwinters8/gomap/client"
+	"github.com/cwinters8/gomap/internal/jmaptest"
 	"github.com/cwinters8/gomap/objects/emails"
 	"github.com/cwinters8/gomap/objects/mailboxes"
 	"github.com/cwinters8/gomap/requests"
@@ -100,3 +104,160 @@ func TestRequest(t *testing.T) {
 		})
 	})
 }
+
+func TestCreatedIDs(t *testing.T) {
+	f := jmaptest.NewServer(t, echo)
+	c := f.NewClient()
+	created := map[string]string{}
+	draft := requests.Call{
+		ID:        requests.NewCallID(),
+		AccountID: "xyz",
+		Method:    "Email/set",
+		Arguments: map[string]any{
+			"create": map[string]any{"draft": map[string]any{"subject": "hello"}},
+		},
+	}
+	if _, err := requests.Request(c, []*requests.Call{&draft}, false, requests.CreatedIDs(created)); err != nil {
+		t.Fatalf("request failure: %s", err.Error())
+	}
+	if created["draft"] != "Mdraft" {
+		t.Fatalf("wanted creation id draft to map to Mdraft; got %v", created)
+	}
+	get := requests.Call{
+		ID:        requests.NewCallID(),
+		AccountID: "xyz",
+		Method:    "Email/get",
+		Arguments: map[string]any{"ids": []string{"#draft"}},
+	}
+	if _, err := requests.Request(c, []*requests.Call{&get}, false, requests.CreatedIDs(created)); err != nil {
+		t.Fatalf("request failure: %s", err.Error())
+	}
+	sent, ok := f.Requests()[1]["createdIds"].(map[string]any)
+	if !ok {
+		t.Fatalf("wanted createdIds in the second request; got %v", f.Requests()[1])
+	}
+	if sent["draft"] != "Mdraft" {
+		t.Errorf("wanted createdIds to carry draft => Mdraft; got %v", sent)
+	}
+
+	t.Run("split request", func(t *testing.T) {
+		f.Reset()
+		c := withLimits(f, &client.CoreCapabilities{MaxCallsInRequest: 1})
+		lookup := requests.Call{
+			ID:        requests.NewCallID(),
+			AccountID: "xyz",
+			Method:    "Email/get",
+			Arguments: map[string]any{"ids": []string{"#draft"}},
+		}
+		if _, err := requests.Request(c, []*requests.Call{&draft, &lookup}, false); err != nil {
+			t.Fatalf("request failure: %s", err.Error())
+		}
+		if len(f.Requests()) != 2 {
+			t.Fatalf("wanted 2 requests; got %d", len(f.Requests()))
+		}
+		sent, ok := f.Requests()[1]["createdIds"].(map[string]any)
+		if !ok || sent["draft"] != "Mdraft" {
+			t.Errorf("wanted createdIds from the first request in the second; got %v", f.Requests()[1]["createdIds"])
+		}
+	})
+
+	t.Run("request size", func(t *testing.T) {
+		f.Reset()
+		c := withLimits(f, &client.CoreCapabilities{MaxSizeRequest: 360})
+		calls := []*requests.Call{}
+		for i := 0; i < 4; i++ {
+			calls = append(calls, &requests.Call{
+				ID:        fmt.Sprintf("s%d", i),
+				AccountID: "xyz",
+				Method:    "Email/set",
+				Arguments: map[string]any{
+					"create": map[string]any{fmt.Sprintf("draft-with-a-long-creation-id-%d", i): map[string]any{"subject": "hello"}},
+				},
+			})
+		}
+		if _, err := requests.Request(c, calls, false, requests.CreatedIDs(map[string]string{})); err != nil {
+			t.Fatalf("request failure: %s", err.Error())
+		}
+		for _, req := range f.Requests() {
+			b, _ := json.Marshal(req)
+			if methodCalls, _ := req["methodCalls"].([]any); len(b) > 360 && len(methodCalls) > 1 {
+				t.Errorf("wanted requests over 360 bytes to carry a single call; got %d bytes with %d calls", len(b), len(methodCalls))
+			}
+		}
+	})
+
+	t.Run("request size with existing createdIds", func(t *testing.T) {
+		f.Reset()
+		c := withLimits(f, &client.CoreCapabilities{MaxSizeRequest: 800})
+		created := map[string]string{}
+		for i := 0; i < 5; i++ {
+			created[fmt.Sprintf("draft-with-a-long-creation-id-%d", i)] = fmt.Sprintf("M%d", i)
+		}
+		ids := []string{}
+		for i := 0; i < 40; i++ {
+			ids = append(ids, fmt.Sprintf("M%08d", i))
+		}
+		get := requests.Call{
+			ID:        requests.NewCallID(),
+			AccountID: "xyz",
+			Method:    "Email/get",
+			Arguments: map[string]any{"ids": ids},
+		}
+		if _, err := requests.Request(c, []*requests.Call{&get}, false, requests.CreatedIDs(created)); err != nil {
+			t.Fatalf("request failure: %s", err.Error())
+		}
+		for _, req := range f.Requests() {
+			b, _ := json.Marshal(req)
+			if len(b) > 800 {
+				t.Errorf("wanted requests of at most 800 bytes; got %d", len(b))
+			}
+		}
+	})
+}
+
+// emailGetResult builds an Email/get response listing n emails.
+func emailGetResult(n int) []byte {
+	list := make([]string, n)
+	for i := range list {
+		list[i] = fmt.Sprintf(`{"id":"M%[1]d","mailboxIds":{"inbox":true},"from":[{"name":"Sender","email":"sender@example.com"}],"to":[{"name":"Recipient","email":"recipient@example.com"}],"subject":"message %[1]d","bodyValues":{"1":{"value":"%[2]s"}},"bodyStructure":{"type":"text/plain"}}`, i, strings.Repeat("lorem ipsum ", 20))
+	}
+	return []byte(fmt.Sprintf(`{"methodResponses":[["Email/get",{"accountId":"u1","state":"1","list":[%s],"notFound":[]},"c1"]],"sessionState":"s1"}`, strings.Join(list, ",")))
+}
+
+// BenchmarkDecode compares decoding each method response straight from its raw json
+// with the previous approach of decoding to map[string]any and marshaling it back.
+func BenchmarkDecode(b *testing.B) {
+	result := emailGetResult(2000)
+
+	b.Run("raw", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var resp requests.Resp
+			if err := json.Unmarshal(result, &resp); err != nil {
+				b.Fatal(err)
+			}
+			if _, _, err := emails.ParseRawResponseBody(resp.MethodResponses[0].Body); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("map", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var resp struct {
+				MethodResponses [][3]any `json:"methodResponses"`
+			}
+			if err := json.Unmarshal(result, &resp); err != nil {
+				b.Fatal(err)
+			}
+			body, err := json.Marshal(resp.MethodResponses[0][1])
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, _, err := emails.ParseRawResponseBody(body); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
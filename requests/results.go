@@ -0,0 +1,115 @@
+package requests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/cwinters8/gomap/client"
+)
+
+// QueryResults are the ids matching a query, in order, kept up to date with Foo/queryChanges
+// so the query doesn't have to be run again.
+type QueryResults struct {
+	QueryState string
+	IDs        []string
+	Total      *int
+	// Limit keeps only the top of the results, as when the query was sent with a limit;
+	// zero keeps every id. Changes are requested up to the last id held.
+	// Type.Refresh sends it as the limit of the query in place of the one in its arguments.
+	Limit int
+}
+
+// NewQueryResults holds the results of a query that started at position zero.
+// limit is the limit the query was sent with, unless the server applied a lower one in resp.
+func NewQueryResults(resp *QueryResponse, limit int) *QueryResults {
+	if resp.Limit != nil && *resp.Limit > 0 {
+		limit = *resp.Limit
+	}
+	return &QueryResults{
+		QueryState: resp.QueryState,
+		IDs:        slices.Clone(resp.IDs),
+		Total:      resp.Total,
+		Limit:      limit,
+	}
+}
+
+// Apply updates the results with changes from the results' query state (RFC 8620 section 5.6):
+// removed ids are taken out, then added ids are inserted at their indexes in ascending order.
+func (r *QueryResults) Apply(changes *QueryChangesResponse) error {
+	if changes.OldQueryState != r.QueryState {
+		return fmt.Errorf("changes are from query state %s; results are in %s", changes.OldQueryState, r.QueryState)
+	}
+	ids := slices.DeleteFunc(slices.Clone(r.IDs), func(id string) bool {
+		return slices.Contains(changes.Removed, id)
+	})
+	added := slices.Clone(changes.Added)
+	slices.SortFunc(added, func(a, b *AddedItem) int {
+		return a.Index - b.Index
+	})
+	for _, item := range added {
+		if item.Index > len(ids) {
+			if r.Limit > 0 {
+				// below the part of the results that's held
+				continue
+			}
+			return fmt.Errorf("added id %s at index %d is beyond the %d results held", item.ID, item.Index, len(ids))
+		}
+		ids = slices.Insert(ids, item.Index, item.ID)
+	}
+	if r.Limit > 0 && len(ids) > r.Limit {
+		ids = ids[:r.Limit]
+	}
+	r.IDs = ids
+	r.QueryState = changes.NewQueryState
+	if changes.Total != nil {
+		r.Total = changes.Total
+	}
+	return nil
+}
+
+// Refresh brings results up to date with the query described by args, through Foo/queryChanges.
+// How many ids are held is up to results.Limit, so Limit, Position and Anchor in args are ignored:
+// the query always runs from the top of the results, with the results' limit.
+//
+// When the results have no query state yet, or the server can't calculate the changes,
+// the query is run again and the results replaced, in which case the returned changes are nil.
+func (t Type[T]) Refresh(ctx context.Context, c *client.Client, acctID string, args *QueryArgs, results *QueryResults) (*QueryChangesResponse, error) {
+	if args == nil {
+		args = &QueryArgs{}
+	}
+	if len(results.QueryState) > 0 {
+		changesArgs := QueryChangesArgs{
+			SinceQueryState: results.QueryState,
+			Filter:          args.Filter,
+			Sort:            args.Sort,
+			CalculateTotal:  args.CalculateTotal,
+			Extra:           args.Extra,
+		}
+		if results.Limit > 0 && len(results.IDs) > 0 {
+			changesArgs.UpToID = results.IDs[len(results.IDs)-1]
+		}
+		changes, err := t.QueryChanges(ctx, c, acctID, &changesArgs)
+		var methodErr *MethodError
+		switch {
+		case err == nil:
+			if err := results.Apply(changes); err != nil {
+				return nil, fmt.Errorf("failed to apply query changes: %w", err)
+			}
+			return changes, nil
+		case !errors.As(err, &methodErr) || (methodErr.Type != ErrorCannotCalculateChanges && methodErr.Type != ErrorTooManyChanges):
+			return nil, err
+		}
+	}
+	queryArgs := *args
+	queryArgs.Position = 0
+	queryArgs.Anchor = ""
+	queryArgs.Limit = results.Limit
+	resp, err := t.Query(ctx, c, acctID, &queryArgs)
+	if err != nil {
+		return nil, err
+	}
+	*results = *NewQueryResults(resp, results.Limit)
+	return nil, nil
+}
@@ -0,0 +1,133 @@
+package requests_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/cwinters8/gomap/internal/jmaptest"
+	"github.com/cwinters8/gomap/requests"
+	"github.com/cwinters8/gomap/utils"
+)
+
+func TestQueryResults(t *testing.T) {
+	t.Run("apply", func(t *testing.T) {
+		// the example of RFC 8620 section 5.6
+		results := &requests.QueryResults{QueryState: "q1", IDs: []string{"a", "b", "c", "d", "e"}}
+		err := results.Apply(&requests.QueryChangesResponse{
+			OldQueryState: "q1",
+			NewQueryState: "q2",
+			Removed:       []string{"b", "e"},
+			Added:         []*requests.AddedItem{{ID: "f", Index: 4}, {ID: "b", Index: 0}},
+		})
+		if err != nil {
+			t.Fatalf("failed to apply changes: %s", err.Error())
+		}
+		cases := utils.Cases{
+			utils.NewCase(!slices.Equal(results.IDs, []string{"b", "a", "c", "d", "f"}), "wanted ids b a c d f; got %v", results.IDs),
+			utils.NewCase(results.QueryState != "q2", "wanted query state q2; got %s", results.QueryState),
+		}
+		cases.Iterator(func(c *utils.Case) {
+			t.Error(c.Message)
+		})
+		if err := results.Apply(&requests.QueryChangesResponse{OldQueryState: "q1", NewQueryState: "q3"}); err == nil {
+			t.Error("wanted an error for changes from another query state")
+		}
+		if err := results.Apply(&requests.QueryChangesResponse{OldQueryState: "q2", Added: []*requests.AddedItem{{ID: "z", Index: 9}}}); err == nil {
+			t.Error("wanted an error for an index beyond the results")
+		}
+	})
+
+	t.Run("apply with limit", func(t *testing.T) {
+		results := &requests.QueryResults{QueryState: "q1", IDs: []string{"a", "b", "c"}, Limit: 3}
+		err := results.Apply(&requests.QueryChangesResponse{
+			OldQueryState: "q1",
+			NewQueryState: "q2",
+			Added:         []*requests.AddedItem{{ID: "x", Index: 0}, {ID: "y", Index: 7}},
+		})
+		if err != nil {
+			t.Fatalf("failed to apply changes: %s", err.Error())
+		}
+		if !slices.Equal(results.IDs, []string{"x", "a", "b"}) {
+			t.Errorf("wanted ids x a b; got %v", results.IDs)
+		}
+	})
+
+	t.Run("refresh", func(t *testing.T) {
+		var last map[string]any
+		methods := []string{}
+		f := jmaptest.NewServer(t, func(method string, args map[string]any) (string, map[string]any) {
+			last = args
+			methods = append(methods, method)
+			switch {
+			case method == "Thing/query":
+				return method, map[string]any{"queryState": "q1", "ids": []any{"T1", "T2"}, "position": 0}
+			case method == "Thing/queryChanges" && args["sinceQueryState"] == "q1":
+				return method, map[string]any{"oldQueryState": "q1", "newQueryState": "q2", "removed": []any{"T1"}, "added": []any{map[string]any{"id": "T3", "index": 0}}}
+			}
+			return "error", map[string]any{"type": "cannotCalculateChanges"}
+		})
+		c := f.NewClient()
+		ctx := context.Background()
+		args := &requests.QueryArgs{Filter: map[string]string{"name": "one"}, Limit: 10}
+		results := &requests.QueryResults{Limit: 2}
+
+		first, err := things.Refresh(ctx, c, "u1", args, results)
+		if err != nil {
+			t.Fatalf("failed to run query: %s", err.Error())
+		}
+		limit := last["limit"]
+		second, err := things.Refresh(ctx, c, "u1", args, results)
+		if err != nil {
+			t.Fatalf("failed to refresh query: %s", err.Error())
+		}
+		cases := utils.Cases{
+			utils.NewCase(first != nil, "wanted no changes for the first query; got %v", first),
+			utils.NewCase(limit != float64(2), "wanted the results' limit of 2 in place of the one in args; got %v", limit),
+			utils.NewCase(second == nil || len(second.Added) != 1, "wanted 1 added id; got %v", second),
+			utils.NewCase(!slices.Equal(results.IDs, []string{"T3", "T2"}), "wanted ids T3 T2; got %v", results.IDs),
+			utils.NewCase(last["upToId"] != "T2", "wanted changes up to T2; got %v", last["upToId"]),
+			utils.NewCase(last["filter"] == nil, "wanted the query's filter to be sent"),
+		}
+		// q2 is unknown to the server, so the query runs again
+		if _, err := things.Refresh(ctx, c, "u1", args, results); err != nil {
+			t.Fatalf("failed to refresh query: %s", err.Error())
+		}
+		cases.Append(
+			utils.NewCase(!slices.Equal(methods, []string{"Thing/query", "Thing/queryChanges", "Thing/queryChanges", "Thing/query"}), "wanted a query again after cannotCalculateChanges; got %v", methods),
+			utils.NewCase(results.QueryState != "q1" || len(results.IDs) != 2, "wanted the results replaced; got %+v", results),
+		)
+		cases.Iterator(func(c *utils.Case) {
+			t.Error(c.Message)
+		})
+	})
+
+	t.Run("refresh with server limit", func(t *testing.T) {
+		var last map[string]any
+		f := jmaptest.NewServer(t, func(method string, args map[string]any) (string, map[string]any) {
+			last = args
+			if method == "Thing/query" {
+				// the server caps every query at 2 results
+				return method, map[string]any{"queryState": "q1", "ids": []any{"T1", "T2"}, "position": 0, "limit": 2}
+			}
+			return method, map[string]any{"oldQueryState": "q1", "newQueryState": "q2", "removed": []any{}, "added": []any{}}
+		})
+		c := f.NewClient()
+		ctx := context.Background()
+		results := &requests.QueryResults{}
+		if _, err := things.Refresh(ctx, c, "u1", nil, results); err != nil {
+			t.Fatalf("failed to run query: %s", err.Error())
+		}
+		limit := results.Limit
+		if _, err := things.Refresh(ctx, c, "u1", nil, results); err != nil {
+			t.Fatalf("failed to refresh query: %s", err.Error())
+		}
+		cases := utils.Cases{
+			utils.NewCase(limit != 2, "wanted the server's limit of 2; got %d", limit),
+			utils.NewCase(last["upToId"] != "T2", "wanted changes up to T2; got %v", last["upToId"]),
+		}
+		cases.Iterator(func(c *utils.Case) {
+			t.Error(c.Message)
+		})
+	})
+}
@@ -0,0 +1,133 @@
+package requests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cwinters8/gomap/client"
+)
+
+// SyncArgs are the arguments of Sync.
+type SyncArgs struct {
+	SinceState string // the state the caller is in sync with; a full resync when empty
+	MaxChanges int    // the page size; zero leaves it to the server
+	// Resync reloads every object when the server can't calculate the changes since SinceState,
+	// returning the state it loaded. It should take the state before loading the objects,
+	// so nothing changed in the meantime is missed.
+	// When nil, Sync returns the current state and leaves reloading to the caller.
+	Resync func(ctx context.Context) (state string, err error)
+}
+
+// Delta is every change between two states, collected from one or more pages of Foo/changes.
+//
+// An object created and destroyed between the states is left out, and an object both
+// created and updated is only listed as created.
+type Delta struct {
+	OldState  string
+	NewState  string
+	Created   []string
+	Updated   []string
+	Destroyed []string
+	// Resynced reports that the server couldn't calculate the changes, so every object
+	// must be reloaded. The id lists are empty and NewState is the state to sync from next.
+	Resynced bool
+}
+
+type change int
+
+const (
+	changeNone change = iota // created and destroyed again
+	changeCreated
+	changeUpdated
+	changeDestroyed
+)
+
+// Sync pages through Foo/changes from args.SinceState until it has caught up with the server.
+//
+// When the server answers cannotCalculateChanges, because the state is too old or unknown,
+// Sync falls back to a full resync with args.Resync.
+func (t Type[T]) Sync(ctx context.Context, c *client.Client, acctID string, args *SyncArgs) (*Delta, error) {
+	if args == nil {
+		args = &SyncArgs{}
+	}
+	if len(args.SinceState) < 1 {
+		return t.resync(ctx, c, acctID, args)
+	}
+	delta := Delta{OldState: args.SinceState, NewState: args.SinceState}
+	changes := map[string]change{}
+	var order []string
+	for {
+		resp, err := t.Changes(ctx, c, acctID, &ChangesArgs{SinceState: delta.NewState, MaxChanges: args.MaxChanges})
+		if err != nil {
+			var methodErr *MethodError
+			if errors.As(err, &methodErr) && methodErr.Type == ErrorCannotCalculateChanges {
+				return t.resync(ctx, c, acctID, args)
+			}
+			return nil, fmt.Errorf("failed to get changes since state %s: %w", delta.NewState, err)
+		}
+		record := func(id string, ch change) {
+			prev, seen := changes[id]
+			switch {
+			case !seen:
+				order = append(order, id)
+				changes[id] = ch
+			case prev == changeCreated && ch == changeDestroyed:
+				changes[id] = changeNone
+			case prev == changeCreated:
+			default:
+				changes[id] = ch
+			}
+		}
+		for _, id := range resp.Created {
+			record(id, changeCreated)
+		}
+		for _, id := range resp.Updated {
+			record(id, changeUpdated)
+		}
+		for _, id := range resp.Destroyed {
+			record(id, changeDestroyed)
+		}
+		if resp.NewState == delta.NewState && resp.HasMoreChanges {
+			return nil, fmt.Errorf("server returned more changes without advancing from state %s", delta.NewState)
+		}
+		delta.NewState = resp.NewState
+		if !resp.HasMoreChanges {
+			break
+		}
+	}
+	for _, id := range order {
+		switch changes[id] {
+		case changeCreated:
+			delta.Created = append(delta.Created, id)
+		case changeUpdated:
+			delta.Updated = append(delta.Updated, id)
+		case changeDestroyed:
+			delta.Destroyed = append(delta.Destroyed, id)
+		}
+	}
+	return &delta, nil
+}
+
+// State returns the current state of the type in the account with acctID, without fetching any objects.
+func (t Type[T]) State(ctx context.Context, c *client.Client, acctID string) (string, error) {
+	resp, err := t.Get(ctx, c, acctID, &GetArgs{IDs: []string{}})
+	if err != nil {
+		return "", err
+	}
+	return resp.State, nil
+}
+
+func (t Type[T]) resync(ctx context.Context, c *client.Client, acctID string, args *SyncArgs) (*Delta, error) {
+	state := ""
+	var err error
+	if args.Resync != nil {
+		state, err = args.Resync(ctx)
+	} else {
+		state, err = t.State(ctx, c, acctID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to resync %s: %w", t.Name, err)
+	}
+	return &Delta{OldState: args.SinceState, NewState: state, Resynced: true}, nil
+}
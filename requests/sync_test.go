@@ -0,0 +1,75 @@
+package requests_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/cwinters8/gomap/internal/jmaptest"
+	"github.com/cwinters8/gomap/requests"
+	"github.com/cwinters8/gomap/utils"
+)
+
+// syncAPI pages through Thing changes from s1 to s3, and can't calculate changes from any other state.
+func syncAPI(method string, args map[string]any) (string, map[string]any) {
+	switch method {
+	case "Thing/changes":
+		switch args["sinceState"] {
+		case "s1":
+			return method, map[string]any{"oldState": "s1", "newState": "s2", "hasMoreChanges": true, "created": []any{"T4", "T5"}, "updated": []any{"T1"}, "destroyed": []any{"T2"}}
+		case "s2":
+			return method, map[string]any{"oldState": "s2", "newState": "s3", "hasMoreChanges": false, "created": []any{}, "updated": []any{"T4", "T3"}, "destroyed": []any{"T5", "T1"}}
+		}
+		return "error", map[string]any{"type": "cannotCalculateChanges"}
+	case "Thing/get":
+		return method, map[string]any{"state": "s3", "list": []any{}, "notFound": []any{}}
+	}
+	return "error", map[string]any{"type": "unknownMethod"}
+}
+
+func TestSync(t *testing.T) {
+	f := jmaptest.NewServer(t, syncAPI)
+	c := f.NewClient()
+	ctx := context.Background()
+
+	t.Run("paging", func(t *testing.T) {
+		delta, err := things.Sync(ctx, c, "u1", &requests.SyncArgs{SinceState: "s1", MaxChanges: 3})
+		if err != nil {
+			t.Fatalf("sync failure: %s", err.Error())
+		}
+		cases := utils.Cases{
+			utils.NewCase(delta.OldState != "s1" || delta.NewState != "s3", "wanted states s1 to s3; got %s to %s", delta.OldState, delta.NewState),
+			utils.NewCase(!slices.Equal(delta.Created, []string{"T4"}), "wanted T4 created; got %v", delta.Created),
+			utils.NewCase(!slices.Equal(delta.Updated, []string{"T3"}), "wanted T3 updated; got %v", delta.Updated),
+			utils.NewCase(!slices.Equal(delta.Destroyed, []string{"T1", "T2"}), "wanted T1 and T2 destroyed; got %v", delta.Destroyed),
+			utils.NewCase(delta.Resynced, "wanted no resync"),
+		}
+		cases.Iterator(func(c *utils.Case) {
+			t.Error(c.Message)
+		})
+	})
+
+	t.Run("resync", func(t *testing.T) {
+		delta, err := things.Sync(ctx, c, "u1", &requests.SyncArgs{SinceState: "s0"})
+		if err != nil {
+			t.Fatalf("sync failure: %s", err.Error())
+		}
+		if !delta.Resynced || delta.NewState != "s3" {
+			t.Errorf("wanted a resync to state s3; got %+v", delta)
+		}
+		reloaded := false
+		delta, err = things.Sync(ctx, c, "u1", &requests.SyncArgs{
+			SinceState: "s0",
+			Resync: func(ctx context.Context) (string, error) {
+				reloaded = true
+				return "s9", nil
+			},
+		})
+		if err != nil {
+			t.Fatalf("sync failure: %s", err.Error())
+		}
+		if !reloaded || !delta.Resynced || delta.NewState != "s9" {
+			t.Errorf("wanted a resync through the callback to state s9; got %+v", delta)
+		}
+	})
+}
@@ -9,6 +9,10 @@ import (
 	"time"
 )
 
+// WriteJSON writes the json documents in raw to a timestamped file named after name in dir.
+//
+// Deprecated: request and response bodies are no longer dumped to disk; add a client.Observer,
+// such as client.NewSlogObserver, to the client instead.
 func WriteJSON(name, dir string, raw map[string][]byte) error {
 	result := map[string]any{}
 	for k, v := range raw {